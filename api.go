@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"todo-app/storage"
+	"todo-app/todo"
+	"todo-app/todostore"
+)
+
+type TodoResponse struct {
+	TraceID string
+	Todo    todo.Item
+}
+
+type DueResponse struct {
+	TraceID  string
+	Overdue  []todo.Item
+	DueToday []todo.Item
+}
+
+type PatchRequest struct {
+	Field    todo.UpdateField
+	NewValue string
+}
+
+func (a *App) Routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /todos", a.ReadHandler)
+	mux.HandleFunc("POST /todos", a.CreateTodoHandler)
+	mux.HandleFunc("POST /todos:batch", a.BatchHandler)
+	mux.HandleFunc("GET /todos/search", a.SearchHandler)
+	mux.HandleFunc("GET /todos/due", a.DueHandler)
+	mux.HandleFunc("GET /todos/blocked", a.BlockedHandler)
+	mux.HandleFunc("GET /todos/ready", a.ReadyHandler)
+	mux.HandleFunc("GET /todos/{id}", a.GetTodoHandler)
+	mux.HandleFunc("PATCH /todos/{id}", a.PatchTodoHandler)
+	mux.HandleFunc("DELETE /todos/{id}", a.DeleteTodoHandler)
+	mux.HandleFunc("GET /todos/{id}/history", a.HistoryHandler)
+	mux.HandleFunc("POST /todos/{id}/dependencies", a.AddDependencyHandler)
+	mux.HandleFunc("DELETE /todos/{id}/dependencies/{dependsOn}", a.RemoveDependencyHandler)
+
+	mux.HandleFunc("GET /trash", a.TrashHandler)
+	mux.HandleFunc("DELETE /trash", a.EmptyTrashHandler)
+	mux.HandleFunc("POST /trash/{id}/restore", a.RestoreHandler)
+	mux.HandleFunc("DELETE /trash/{id}", a.PurgeHandler)
+
+	mux.HandleFunc("POST /undo", a.UndoHandler)
+	mux.HandleFunc("POST /redo", a.RedoHandler)
+
+	mux.HandleFunc("GET /lists", a.ListsHandler)
+	mux.HandleFunc("PATCH /lists/{name}", a.RenameListHandler)
+	mux.HandleFunc("DELETE /lists/{name}", a.DeleteListHandler)
+	mux.HandleFunc("GET /lists/{name}/todos", a.ReadHandler)
+	mux.HandleFunc("POST /lists/{name}/todos", a.CreateTodoHandler)
+	mux.Handle("GET /lists/{name}/todos/{id}", a.inList(http.HandlerFunc(a.GetTodoHandler)))
+	mux.Handle("PATCH /lists/{name}/todos/{id}", a.inList(http.HandlerFunc(a.PatchTodoHandler)))
+	mux.Handle("DELETE /lists/{name}/todos/{id}", a.inList(http.HandlerFunc(a.DeleteTodoHandler)))
+
+	mux.Handle("POST /create", DeprecatedMiddleware("/todos", http.HandlerFunc(a.CreateHandler)))
+	mux.Handle("GET /read", DeprecatedMiddleware("/todos", http.HandlerFunc(a.ReadHandler)))
+	mux.Handle("PATCH /update", DeprecatedMiddleware("/todos/{id}", http.HandlerFunc(a.UpdateHandler)))
+	mux.Handle("DELETE /delete", DeprecatedMiddleware("/todos/{id}", http.HandlerFunc(a.DeleteHandler)))
+
+	mux.HandleFunc("GET /list", a.ListPageHandler)
+	mux.Handle("GET /about/", http.StripPrefix("/about/", http.FileServer(http.Dir("static"))))
+	return mux
+}
+
+func (a *App) CreateTodoHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+
+	var item todo.Item
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON", traceID)
+		slog.ErrorContext(ctx, "failed to decode request", "traceID", traceID, "error", err)
+		return
+	}
+
+	if name := r.PathValue("name"); name != "" {
+		item.List = name
+	}
+
+	slog.InfoContext(ctx, "Creating todo", "desc", item.Description, "list", item.List, "traceID", traceID)
+
+	created, err := todostore.AddItem(ctx, item, a.Store)
+	if err != nil {
+		writeStoreError(w, err, "failed to create item", traceID)
+		slog.ErrorContext(ctx, "failed to create item", "traceID", traceID, "error", err)
+		return
+	}
+
+	w.Header().Set("Location", "/todos/"+created.ID)
+	writeJSON(w, http.StatusCreated, TodoResponse{TraceID: traceID, Todo: created})
+}
+
+func (a *App) GetTodoHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+	id := r.PathValue("id")
+
+	var item todo.Item
+	var err error
+	if list, ok := ctx.Value(listKey).(string); ok {
+		item, err = todostore.GetInList(ctx, list, id, a.Store)
+	} else {
+		item, err = todostore.Get(ctx, id, a.Store)
+	}
+	if err != nil {
+		writeStoreError(w, err, "failed to fetch item", traceID)
+		slog.ErrorContext(ctx, "failed to fetch item", "id", id, "traceID", traceID, "error", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, TodoResponse{TraceID: traceID, Todo: item})
+}
+
+func (a *App) PatchTodoHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+	id := r.PathValue("id")
+
+	var request PatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON", traceID)
+		slog.ErrorContext(ctx, "failed to decode request", "traceID", traceID, "error", err)
+		return
+	}
+
+	slog.InfoContext(ctx, "Updating todo", "id", id, "field", request.Field, "traceID", traceID)
+
+	var err error
+	if list, ok := ctx.Value(listKey).(string); ok {
+		err = todostore.UpdateInList(ctx, list, id, request.Field, request.NewValue, a.Store)
+	} else {
+		err = todostore.UpdateByID(ctx, id, request.Field, request.NewValue, a.Store)
+	}
+	if err != nil {
+		writeStoreError(w, err, "failed to update item", traceID)
+		slog.ErrorContext(ctx, "failed to update item", "id", id, "traceID", traceID, "error", err)
+		return
+	}
+
+	item, err := todostore.Get(ctx, id, a.Store)
+	if err != nil {
+		writeStoreError(w, err, "failed to fetch item", traceID)
+		slog.ErrorContext(ctx, "failed to fetch item", "id", id, "traceID", traceID, "error", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, TodoResponse{TraceID: traceID, Todo: item})
+}
+
+func (a *App) DeleteTodoHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+	id := r.PathValue("id")
+
+	slog.InfoContext(ctx, "Deleting todo", "id", id, "traceID", traceID)
+
+	var err error
+	if list, ok := ctx.Value(listKey).(string); ok {
+		err = todostore.RemoveInList(ctx, list, id, a.Store)
+	} else {
+		err = todostore.RemoveByID(ctx, id, a.Store)
+	}
+	if err != nil {
+		writeStoreError(w, err, "failed to delete item", traceID)
+		slog.ErrorContext(ctx, "failed to delete item", "id", id, "traceID", traceID, "error", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "Todo deleted",
+		"traceID": traceID,
+	})
+}
+
+func (a *App) DueHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+	now := time.Now()
+
+	overdue, err := todostore.Overdue(ctx, now, a.Store)
+	if err != nil {
+		writeStoreError(w, err, "failed to fetch overdue items", traceID)
+		slog.ErrorContext(ctx, "failed to fetch overdue items", "traceID", traceID, "error", err)
+		return
+	}
+
+	dueToday, err := todostore.DueToday(ctx, now, a.Store)
+	if err != nil {
+		writeStoreError(w, err, "failed to fetch items due today", traceID)
+		slog.ErrorContext(ctx, "failed to fetch items due today", "traceID", traceID, "error", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, DueResponse{TraceID: traceID, Overdue: overdue, DueToday: dueToday})
+}
+
+// writeStoreError maps errors returned by todostore to a status code. Errors
+// that are not caused by the request are reported with a generic message.
+func writeStoreError(w http.ResponseWriter, err error, message, traceID string) {
+	status := storeErrorStatus(err)
+	if status < http.StatusInternalServerError {
+		message = err.Error()
+	}
+	writeError(w, status, message, traceID)
+}
+
+// storeErrorStatus is the HTTP status that reports err. Only client errors
+// are safe to show to the caller as they are.
+func storeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, todo.ErrItemNotFound),
+		errors.Is(err, todo.ErrListNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, todo.ErrItemExists),
+		errors.Is(err, todo.ErrDuplicateDesc),
+		errors.Is(err, todo.ErrListExists),
+		errors.Is(err, todo.ErrHasSubtasks),
+		errors.Is(err, todo.ErrBlocked),
+		errors.Is(err, todo.ErrDependencyCycle),
+		errors.Is(err, todostore.ErrNothingToUndo),
+		errors.Is(err, todostore.ErrNothingToRedo),
+		errors.Is(err, todostore.ErrUndoConflict),
+		errors.Is(err, todo.ErrNotInTrash):
+		return http.StatusConflict
+	case errors.Is(err, todo.ErrItemIsEmpty),
+		errors.Is(err, todo.ErrInvalidStatus),
+		errors.Is(err, todo.ErrInvalidDueDate),
+		errors.Is(err, todo.ErrInvalidPriority),
+		errors.Is(err, todo.ErrInvalidTag),
+		errors.Is(err, todo.ErrInvalidList),
+		errors.Is(err, todo.ErrInvalidRecurrence),
+		errors.Is(err, todostore.ErrInvalidUpdateField),
+		errors.Is(err, todostore.ErrInvalidSort),
+		errors.Is(err, todostore.ErrInvalidQuery),
+		errors.Is(err, todostore.ErrInvalidCursor),
+		errors.Is(err, todostore.ErrEmptySearch),
+		errors.Is(err, errInvalidInclude),
+		errors.Is(err, todostore.ErrInvalidBatchOp),
+		errors.Is(err, todostore.ErrNoActor):
+		return http.StatusBadRequest
+	case errors.Is(err, storage.ErrStoreClosed):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message, traceID string) {
+	writeJSON(w, status, map[string]string{
+		"error":   message,
+		"traceID": traceID,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"unicode"
+
+	"todo-app/storage"
+	"todo-app/todo"
+	"todo-app/todostore"
+)
+
+type BatchRequest struct {
+	Operations []todostore.Operation
+}
+
+// BatchResponse holds one result per operation, in order. Error explains
+// why a batch was refused.
+type BatchResponse struct {
+	TraceID string
+	Results []todostore.Result
+	Error   string `json:",omitempty"`
+}
+
+func (a *App) BatchHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+
+	var request BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON", traceID)
+		slog.ErrorContext(ctx, "failed to decode request", "traceID", traceID, "error", err)
+		return
+	}
+
+	slog.InfoContext(ctx, "Applying batch", "operations", len(request.Operations), "traceID", traceID)
+
+	results, err := todostore.Batch(ctx, request.Operations, a.Store)
+	if err != nil {
+		status := storeErrorStatus(err)
+		message := "failed to apply batch"
+		if status < http.StatusInternalServerError {
+			message = err.Error()
+		}
+		writeJSON(w, status, BatchResponse{TraceID: traceID, Results: results, Error: message})
+		slog.ErrorContext(ctx, "failed to apply batch", "traceID", traceID, "error", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, BatchResponse{TraceID: traceID, Results: results})
+}
+
+// runBatch applies the commands read from path, or from stdin when it is
+// "-", as one batch. Each line holds the flags of one add, update or remove
+// as they would be given on the command line; blank lines and lines starting
+// with # are skipped. list is the list of lines that do not name one.
+func runBatch(ctx context.Context, path, list string, store storage.Store) {
+	traceID := ctx.Value(traceIDKey).(string)
+
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to open batch file", "path", path, "traceID", traceID, "error", err)
+			return
+		}
+		defer file.Close()
+		input = file
+	}
+
+	var ops []todostore.Operation
+	var lines []int
+	scanner := bufio.NewScanner(input)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		op, err := parseBatchLine(line, list)
+		if err != nil {
+			slog.ErrorContext(ctx, "invalid batch command", "line", n, "traceID", traceID, "error", err)
+			return
+		}
+		ops = append(ops, op)
+		lines = append(lines, n)
+	}
+	if err := scanner.Err(); err != nil {
+		slog.ErrorContext(ctx, "failed to read batch", "path", path, "traceID", traceID, "error", err)
+		return
+	}
+
+	slog.InfoContext(ctx, "Applying batch", "operations", len(ops), "traceID", traceID)
+	results, err := todostore.Batch(ctx, ops, store)
+	var failed *todostore.BatchError
+	switch {
+	case errors.As(err, &failed):
+		slog.ErrorContext(ctx, "failed to apply batch, nothing was changed", "line", lines[failed.Index], "traceID", traceID, "error", failed.Err)
+		return
+	case err != nil:
+		slog.ErrorContext(ctx, "failed to apply batch", "traceID", traceID, "error", err)
+		return
+	}
+
+	verbs := map[todostore.BatchOp]string{
+		todostore.BatchCreate: "Created",
+		todostore.BatchUpdate: "Updated",
+		todostore.BatchDelete: "Removed",
+	}
+	for _, result := range results {
+		fmt.Printf("%s %q\n", verbs[result.Op], result.Item.Description)
+	}
+	fmt.Printf("Applied %d operation(s)\n", len(results))
+}
+
+// parseBatchLine turns one line of a batch into an operation: -add with
+// -priority, -due, -tag, -repeat, -notes and -list; -find with one of
+// -update-status, -update-description, -due, -priority, -move-to, -repeat
+// and -notes; or -remove.
+func parseBatchLine(line, list string) (todostore.Operation, error) {
+	args, err := splitArgs(line)
+	if err != nil {
+		return todostore.Operation{}, err
+	}
+
+	flags := flag.NewFlagSet("batch", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	add := flags.String("add", "", "")
+	find := flags.String("find", "", "")
+	remove := flags.String("remove", "", "")
+	flags.StringVar(&list, "list", list, "")
+	var tags stringList
+	flags.Var(&tags, "tag", "")
+	fields := map[todo.UpdateField]*string{
+		todo.UpdateFieldStatus:      flags.String("update-status", "", ""),
+		todo.UpdateFieldDescription: flags.String("update-description", "", ""),
+		todo.UpdateFieldDue:         flags.String("due", "", ""),
+		todo.UpdateFieldPriority:    flags.String("priority", "", ""),
+		todo.UpdateFieldList:        flags.String("move-to", "", ""),
+		todo.UpdateFieldRecurrence:  flags.String("repeat", "", ""),
+		todo.UpdateFieldNotes:       flags.String("notes", "", ""),
+	}
+	if err := flags.Parse(args); err != nil {
+		return todostore.Operation{}, err
+	}
+	if flags.NArg() > 0 {
+		return todostore.Operation{}, fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+
+	switch {
+	case *add != "":
+		draft := todo.Item{
+			Description: *add,
+			Priority:    *fields[todo.UpdateFieldPriority],
+			Tags:        tags,
+			List:        list,
+			Recurrence:  *fields[todo.UpdateFieldRecurrence],
+			Notes:       *fields[todo.UpdateFieldNotes],
+		}
+		if due := *fields[todo.UpdateFieldDue]; due != "" {
+			if draft.Due, err = todo.ParseDueDate(due); err != nil {
+				return todostore.Operation{}, err
+			}
+		}
+		return todostore.Operation{Op: todostore.BatchCreate, Item: draft}, nil
+
+	case *remove != "":
+		return todostore.Operation{Op: todostore.BatchDelete, List: list, Description: *remove}, nil
+
+	case *find != "":
+		op := todostore.Operation{Op: todostore.BatchUpdate, List: list, Description: *find}
+		for field, value := range fields {
+			if *value == "" {
+				continue
+			}
+			if op.Field != "" {
+				return todostore.Operation{}, errors.New("-find takes one change per line")
+			}
+			op.Field, op.NewValue = field, *value
+		}
+		if op.Field == "" {
+			return todostore.Operation{}, errors.New("-find needs a change such as -update-status")
+		}
+		return op, nil
+	}
+	return todostore.Operation{}, errors.New("expected -add, -find or -remove")
+}
+
+// splitArgs splits a line into arguments at white space outside of single
+// or double quotes.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			arg.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote, inArg = r, true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"todo-app/todostore"
+)
+
+type DependencyRequest struct {
+	ID string
+}
+
+func (a *App) BlockedHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+
+	todos, err := todostore.Blocked(ctx, a.Store)
+	if err != nil {
+		writeStoreError(w, err, "failed to fetch blocked items", traceID)
+		slog.ErrorContext(ctx, "failed to fetch blocked items", "traceID", traceID, "error", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, TodosResponse{TraceID: traceID, Todos: todos})
+}
+
+func (a *App) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+
+	todos, err := todostore.Ready(ctx, a.Store)
+	if err != nil {
+		writeStoreError(w, err, "failed to fetch ready items", traceID)
+		slog.ErrorContext(ctx, "failed to fetch ready items", "traceID", traceID, "error", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, TodosResponse{TraceID: traceID, Todos: todos})
+}
+
+func (a *App) AddDependencyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+	id := r.PathValue("id")
+
+	var request DependencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON", traceID)
+		slog.ErrorContext(ctx, "failed to decode request", "traceID", traceID, "error", err)
+		return
+	}
+
+	slog.InfoContext(ctx, "Adding dependency", "id", id, "dependsOn", request.ID, "traceID", traceID)
+
+	if err := todostore.AddDependencyByID(ctx, id, request.ID, a.Store); err != nil {
+		writeStoreError(w, err, "failed to add dependency", traceID)
+		slog.ErrorContext(ctx, "failed to add dependency", "id", id, "traceID", traceID, "error", err)
+		return
+	}
+
+	a.GetTodoHandler(w, r)
+}
+
+func (a *App) RemoveDependencyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+	id, dependsOn := r.PathValue("id"), r.PathValue("dependsOn")
+
+	slog.InfoContext(ctx, "Removing dependency", "id", id, "dependsOn", dependsOn, "traceID", traceID)
+
+	if err := todostore.RemoveDependencyByID(ctx, id, dependsOn, a.Store); err != nil {
+		writeStoreError(w, err, "failed to remove dependency", traceID)
+		slog.ErrorContext(ctx, "failed to remove dependency", "id", id, "traceID", traceID, "error", err)
+		return
+	}
+
+	a.GetTodoHandler(w, r)
+}
@@ -3,17 +3,22 @@ package main
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"html/template"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"todo-app/storage"
 	"todo-app/todo"
 	"todo-app/todostore"
 )
 
+var errInvalidInclude = errors.New("invalid include")
+
 type App struct {
-	FS *storage.FileStore
+	Store storage.Store
 }
 
 type TodosResponse struct {
@@ -21,7 +26,18 @@ type TodosResponse struct {
 	Todos   []todo.Item
 }
 
+// TodoPageResponse is one page of a listing. NextCursor fetches the page
+// after it and is left out on the last one.
+type TodoPageResponse struct {
+	TraceID    string
+	Todos      []todo.Item
+	Total      int
+	NextCursor string `json:",omitempty"`
+}
+
 type UpdateRequest struct {
+	ID          string
+	List        string
 	Description string
 	Field       todo.UpdateField
 	NewValue    string
@@ -40,21 +56,9 @@ func (a *App) CreateHandler(w http.ResponseWriter, r *http.Request) {
 
 	slog.InfoContext(ctx, "Creating todo", "desc", item.Description, "traceID", traceID)
 
-	if err := todostore.Add(ctx, item.Description, a.FS); err != nil {
-		if errors.Is(err, todo.ErrItemExists) ||
-			errors.Is(err, todo.ErrItemIsEmpty) {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error":   err.Error(),
-				"traceID": traceID,
-			})
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error":   "failed to create item",
-				"traceID": traceID,
-			})
-		}
+	created, err := todostore.AddItem(ctx, item, a.Store)
+	if err != nil {
+		writeStoreError(w, err, "failed to create item", traceID)
 		slog.ErrorContext(ctx, "failed to create item", "traceID", traceID, "error", err)
 		return
 	}
@@ -62,6 +66,7 @@ func (a *App) CreateHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{
 		"message": "Todo created",
+		"id":      created.ID,
 		"traceID": traceID,
 	})
 }
@@ -70,18 +75,23 @@ func (a *App) ReadHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	traceID := ctx.Value(traceIDKey).(string)
 
-	todos, err := a.FS.LoadTodos(ctx)
+	q, err := listQuery(r)
+	var page todostore.Page
+	if err == nil {
+		page, err = todostore.List(ctx, q, a.Store)
+	}
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"traceID": traceID})
+		writeStoreError(w, err, "failed to fetch todo items", traceID)
 		slog.ErrorContext(ctx, "failed to fetch todo items", "traceID", traceID, "error", err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(TodosResponse{
-		TraceID: traceID,
-		Todos:   todos,
+	json.NewEncoder(w).Encode(TodoPageResponse{
+		TraceID:    traceID,
+		Todos:      page.Items,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
 	})
 }
 
@@ -96,24 +106,15 @@ func (a *App) UpdateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	slog.InfoContext(ctx, "Updating todo", "desc", request.Description)
-	if err := todostore.Update(ctx, request.Description, request.Field, request.NewValue, a.FS); err != nil {
-		if errors.Is(err, todostore.ErrInvalidUpdateField) ||
-			errors.Is(err, todo.ErrDuplicateDesc) ||
-			errors.Is(err, todo.ErrInvalidStatus) ||
-			errors.Is(err, todo.ErrItemNotFound) {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error":   err.Error(),
-				"traceID": traceID,
-			})
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error":   "failed to update item",
-				"traceID": traceID,
-			})
-		}
+	slog.InfoContext(ctx, "Updating todo", "id", request.ID, "desc", request.Description)
+	var err error
+	if request.ID != "" {
+		err = todostore.UpdateByID(ctx, request.ID, request.Field, request.NewValue, a.Store)
+	} else {
+		err = todostore.Update(ctx, request.List, request.Description, request.Field, request.NewValue, a.Store)
+	}
+	if err != nil {
+		writeStoreError(w, err, "failed to update item", traceID)
 		slog.ErrorContext(ctx, "failed to update item", "traceID", traceID, "error", err)
 		return
 	}
@@ -136,22 +137,16 @@ func (a *App) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	slog.InfoContext(ctx, "Deleting todo", "desc", item.Description, "traceID", traceID)
-
-	if err := todostore.Remove(ctx, item.Description, a.FS); err != nil {
-		if errors.Is(err, todo.ErrItemNotFound) {
-			w.WriteHeader(http.StatusNotFound)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error":   "item not found",
-				"traceID": traceID,
-			})
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error":   "failed to delete item",
-				"traceID": traceID,
-			})
-		}
+	slog.InfoContext(ctx, "Deleting todo", "id", item.ID, "desc", item.Description, "traceID", traceID)
+
+	var err error
+	if item.ID != "" {
+		err = todostore.RemoveByID(ctx, item.ID, a.Store)
+	} else {
+		err = todostore.Remove(ctx, item.List, item.Description, a.Store)
+	}
+	if err != nil {
+		writeStoreError(w, err, "failed to delete item", traceID)
 		slog.ErrorContext(ctx, "failed to delete item", "traceID", traceID, "error", err)
 		return
 	}
@@ -167,9 +162,17 @@ func (a *App) ListPageHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	traceID := ctx.Value(traceIDKey).(string)
 
-	todos, err := a.FS.LoadTodos(ctx)
+	q, err := listQuery(r)
+	var page todostore.Page
+	if err == nil {
+		page, err = todostore.List(ctx, q, a.Store)
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to load todos", "traceID", traceID, "error", err)
+		if status := storeErrorStatus(err); status < http.StatusInternalServerError {
+			http.Error(w, err.Error(), status)
+			return
+		}
 		http.Error(w, "Failed to load todos", http.StatusInternalServerError)
 		return
 	}
@@ -181,8 +184,60 @@ func (a *App) ListPageHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := tmpl.Execute(w, todos); err != nil {
+	if err := tmpl.Execute(w, todo.Tree(page.Items)); err != nil {
 		slog.ErrorContext(ctx, "failed to execute template", "traceID", traceID, "error", err)
 		http.Error(w, "Template execution error", http.StatusInternalServerError)
 	}
 }
+
+// listQuery reads the query parameters of listings: filters, the sort
+// order and which page to return.
+func listQuery(r *http.Request) (todostore.Query, error) {
+	query := r.URL.Query()
+	q := todostore.Query{
+		Status: query.Get("status"),
+		Text:   query.Get("text"),
+		Tags:   query["tag"],
+		List:   query.Get("list"),
+		Sort:   todostore.SortOrder(query.Get("sort")),
+		Cursor: query.Get("cursor"),
+	}
+	if name := r.PathValue("name"); name != "" {
+		q.List = name
+	}
+
+	archived, err := includesArchived(query)
+	if err != nil {
+		return q, err
+	}
+	q.Archived = archived
+
+	for _, param := range []struct {
+		name  string
+		field *int
+	}{{"limit", &q.Limit}, {"offset", &q.Offset}} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return q, fmt.Errorf("%w: %s %q is not a number", todostore.ErrInvalidQuery, param.name, value)
+		}
+		*param.field = n
+	}
+	return q, nil
+}
+
+// includesArchived reads the include query parameter, which can ask for
+// archived items.
+func includesArchived(query url.Values) (bool, error) {
+	switch include := query.Get("include"); include {
+	case "":
+		return false, nil
+	case "archived":
+		return true, nil
+	default:
+		return false, fmt.Errorf("%w: %s - valid values are: archived", errInvalidInclude, include)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"todo-app/storage"
+	"todo-app/todostore"
+)
+
+type HistoryResponse struct {
+	TraceID string
+	Events  []storage.Event
+}
+
+func (a *App) HistoryHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+	id := r.PathValue("id")
+
+	events, err := todostore.HistoryByID(ctx, id, a.Store)
+	if err != nil {
+		writeStoreError(w, err, "failed to fetch history", traceID)
+		slog.ErrorContext(ctx, "failed to fetch history", "id", id, "traceID", traceID, "error", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, HistoryResponse{TraceID: traceID, Events: events})
+}
+
+type UndoResponse struct {
+	TraceID    string
+	Operations int
+}
+
+// UndoHandler reverts the caller's last operations, one unless ?count= says
+// otherwise. The caller is the actor named by the X-Actor header.
+func (a *App) UndoHandler(w http.ResponseWriter, r *http.Request) {
+	a.replay(w, r, "undo", todostore.Undo)
+}
+
+// RedoHandler re-applies operations reverted by UndoHandler.
+func (a *App) RedoHandler(w http.ResponseWriter, r *http.Request) {
+	a.replay(w, r, "redo", todostore.Redo)
+}
+
+func (a *App) replay(w http.ResponseWriter, r *http.Request, name string, replay func(context.Context, int, storage.Store) (int, error)) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+
+	count := 1
+	if value := r.URL.Query().Get("count"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "count must be a positive number", traceID)
+			slog.ErrorContext(ctx, "invalid "+name+" count", "count", value, "traceID", traceID)
+			return
+		}
+		count = n
+	}
+
+	slog.InfoContext(ctx, "Replaying operations", "action", name, "count", count, "actor", storage.Actor(ctx), "traceID", traceID)
+
+	done, err := replay(ctx, count, a.Store)
+	if err != nil && done == 0 {
+		writeStoreError(w, err, "failed to "+name, traceID)
+		slog.ErrorContext(ctx, "failed to "+name, "traceID", traceID, "error", err)
+		return
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "stopped "+name+" early", "done", done, "traceID", traceID, "error", err)
+	}
+
+	writeJSON(w, http.StatusOK, UndoResponse{TraceID: traceID, Operations: done})
+}
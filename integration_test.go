@@ -9,11 +9,13 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
 	"todo-app/storage"
 	"todo-app/todo"
+	"todo-app/todostore"
 )
 
 func startTestServer(t *testing.T) (baseURL string, cleanup func()) {
@@ -27,20 +29,14 @@ func startTestServer(t *testing.T) (baseURL string, cleanup func()) {
 	}
 
 	fs := storage.NewFileStore(tmpFile)
-	app := &App{FS: fs}
-
-	mux := http.NewServeMux()
-	mux.HandleFunc("/create", app.CreateHandler)
-	mux.HandleFunc("/read", app.ReadHandler)
-	mux.HandleFunc("/update", app.UpdateHandler)
-	mux.HandleFunc("/delete", app.DeleteHandler)
+	app := &App{Store: fs}
 
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
 		t.Fatalf("failed to start listener: %v", err)
 	}
 
-	server := &http.Server{Handler: TraceMiddleware(mux)}
+	server := &http.Server{Handler: TraceMiddleware(app.Routes())}
 	go server.Serve(listener)
 
 	cleanup = func() {
@@ -53,6 +49,13 @@ func startTestServer(t *testing.T) (baseURL string, cleanup func()) {
 
 func doRequest(t *testing.T, client *http.Client, method string, url string, body any) *http.Response {
 	t.Helper()
+	return doRequestAs(t, client, "", method, url, body)
+}
+
+// doRequestAs sends a request on behalf of actor, or anonymously when actor
+// is empty.
+func doRequestAs(t *testing.T, client *http.Client, actor, method string, url string, body any) *http.Response {
+	t.Helper()
 
 	var buf io.Reader
 	if body != nil {
@@ -67,6 +70,9 @@ func doRequest(t *testing.T, client *http.Client, method string, url string, bod
 		t.Fatalf("failed to create request: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if actor != "" {
+		req.Header.Set(actorHeader, actor)
+	}
 	resp, err := client.Do(req)
 	if err != nil {
 		t.Fatalf("request failed: %v", err)
@@ -87,6 +93,8 @@ func TestCreateAndReadFlow(t *testing.T) {
 	}{
 		{"valid item", todo.Item{Description: "wash car"}, http.StatusCreated},
 		{"empty description", todo.Item{Description: ""}, http.StatusBadRequest},
+		{"duplicate", todo.Item{Description: "wash car"}, http.StatusConflict},
+		{"unknown dependency", todo.Item{Description: "dry car", DependsOn: []string{"missing"}}, http.StatusNotFound},
 	}
 
 	for _, tt := range tests {
@@ -117,6 +125,26 @@ func TestCreateAndReadFlow(t *testing.T) {
 	}
 }
 
+func TestLegacyCreateBlocked(t *testing.T) {
+	baseURL, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: time.Second}
+
+	resp := doRequest(t, client, http.MethodPost, baseURL+"/create", todo.Item{Description: "buy wax"})
+	var created map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode create response: %v", err)
+	}
+	resp.Body.Close()
+
+	blocked := todo.Item{Description: "wax car", Status: todo.Completed, DependsOn: []string{created["id"]}}
+	resp = doRequest(t, client, http.MethodPost, baseURL+"/create", blocked)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Create blocked item status = %v, want %v", resp.StatusCode, http.StatusConflict)
+	}
+}
+
 func TestUpdateHandlerIntegration(t *testing.T) {
 	baseURL, cleanup := startTestServer(t)
 	defer cleanup()
@@ -208,3 +236,895 @@ func TestDeleteHandlerIntegration(t *testing.T) {
 		t.Errorf("expected 0 todos, got %d", len(readResp.Todos))
 	}
 }
+
+func TestUpdateAndDeleteByIDIntegration(t *testing.T) {
+	baseURL, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: time.Second}
+
+	resp := doRequest(t, client, http.MethodPost, baseURL+"/create", todo.Item{Description: "water plants"})
+	defer resp.Body.Close()
+	var created map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode create response: %v", err)
+	}
+	id := created["id"]
+	if id == "" {
+		t.Fatalf("expected create response to include an id")
+	}
+
+	update := UpdateRequest{ID: id, Field: todo.UpdateFieldDescription, NewValue: "water garden"}
+	resp = doRequest(t, client, http.MethodPatch, baseURL+"/update", update)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Update status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	resp = doRequest(t, client, http.MethodDelete, baseURL+"/delete", todo.Item{ID: id})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Delete status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	resp = doRequest(t, client, http.MethodDelete, baseURL+"/delete", todo.Item{ID: id})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Delete status = %v, want %v", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestTodoResourceRoutes(t *testing.T) {
+	baseURL, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: time.Second}
+
+	resp := doRequest(t, client, http.MethodPost, baseURL+"/todos", todo.Item{Description: "feed cat"})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Create status = %v, want %v", resp.StatusCode, http.StatusCreated)
+	}
+	var created TodoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode create response: %v", err)
+	}
+	location := "/todos/" + created.Todo.ID
+	if got := resp.Header.Get("Location"); got != location {
+		t.Errorf("Location = %q, want %q", got, location)
+	}
+
+	patch := PatchRequest{Field: todo.UpdateFieldStatus, NewValue: todo.Started}
+	resp = doRequest(t, client, http.MethodPatch, baseURL+location, patch)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Patch status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	resp = doRequest(t, client, http.MethodGet, baseURL+location, nil)
+	defer resp.Body.Close()
+	var fetched TodoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&fetched); err != nil {
+		t.Fatalf("failed to decode get response: %v", err)
+	}
+	if fetched.Todo.Status != todo.Started {
+		t.Errorf("expected status %q, got %q", todo.Started, fetched.Todo.Status)
+	}
+
+	resp = doRequest(t, client, http.MethodDelete, baseURL+location, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Delete status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	resp = doRequest(t, client, http.MethodGet, baseURL+location, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Get status = %v, want %v", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestMethodEnforcement(t *testing.T) {
+	baseURL, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: time.Second}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		wantStatus     int
+		wantDeprecated bool
+	}{
+		{"wrong method on collection", http.MethodDelete, "/todos", http.StatusMethodNotAllowed, false},
+		{"wrong method on item", http.MethodPost, "/todos/some-id", http.StatusMethodNotAllowed, false},
+		{"wrong method on legacy delete", http.MethodGet, "/delete", http.StatusMethodNotAllowed, false},
+		{"wrong method on legacy read", http.MethodPost, "/read", http.StatusMethodNotAllowed, false},
+		{"legacy read", http.MethodGet, "/read", http.StatusOK, true},
+		{"collection read", http.MethodGet, "/todos", http.StatusOK, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doRequest(t, client, tt.method, baseURL+tt.path, nil)
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %v, want %v", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusMethodNotAllowed && resp.Header.Get("Allow") == "" {
+				t.Errorf("expected Allow header on 405 response")
+			}
+			if deprecated := resp.Header.Get("Deprecation") != ""; deprecated != tt.wantDeprecated {
+				t.Errorf("deprecated = %v, want %v", deprecated, tt.wantDeprecated)
+			}
+		})
+	}
+}
+
+func TestDueEndpoint(t *testing.T) {
+	baseURL, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: time.Second}
+
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	today := time.Now().Format("2006-01-02")
+	for _, body := range []map[string]string{
+		{"description": "pay rent", "due": yesterday},
+		{"description": "call mum", "due": today},
+		{"description": "someday"},
+	} {
+		resp := doRequest(t, client, http.MethodPost, baseURL+"/todos", body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Create status = %v, want %v", resp.StatusCode, http.StatusCreated)
+		}
+	}
+
+	resp := doRequest(t, client, http.MethodPost, baseURL+"/todos", map[string]string{"description": "bad", "due": "soon"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Create with invalid due status = %v, want %v", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	resp = doRequest(t, client, http.MethodGet, baseURL+"/todos/due", nil)
+	defer resp.Body.Close()
+	var dueResp DueResponse
+	if err := json.NewDecoder(resp.Body).Decode(&dueResp); err != nil {
+		t.Fatalf("failed to decode due response: %v", err)
+	}
+	if len(dueResp.Overdue) != 1 || dueResp.Overdue[0].Description != "pay rent" {
+		t.Errorf("unexpected overdue items: %+v", dueResp.Overdue)
+	}
+	if len(dueResp.DueToday) != 1 || dueResp.DueToday[0].Description != "call mum" {
+		t.Errorf("unexpected items due today: %+v", dueResp.DueToday)
+	}
+}
+
+func TestReadSortedByPriority(t *testing.T) {
+	baseURL, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: time.Second}
+
+	for _, body := range []map[string]string{
+		{"description": "tidy desk", "priority": todo.PriorityLow},
+		{"description": "fix outage", "priority": todo.PriorityUrgent},
+		{"description": "review pr"},
+	} {
+		resp := doRequest(t, client, http.MethodPost, baseURL+"/todos", body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Create status = %v, want %v", resp.StatusCode, http.StatusCreated)
+		}
+	}
+
+	resp := doRequest(t, client, http.MethodGet, baseURL+"/read?sort=priority", nil)
+	defer resp.Body.Close()
+	var readResp TodosResponse
+	if err := json.NewDecoder(resp.Body).Decode(&readResp); err != nil {
+		t.Fatalf("failed to decode read response: %v", err)
+	}
+	want := []string{"fix outage", "review pr", "tidy desk"}
+	for i, desc := range want {
+		if i >= len(readResp.Todos) || readResp.Todos[i].Description != desc {
+			t.Fatalf("expected order %v, got %+v", want, readResp.Todos)
+		}
+	}
+
+	resp = doRequest(t, client, http.MethodGet, baseURL+"/todos?sort=colour", nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Read with invalid sort status = %v, want %v", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestReadFilteredByTag(t *testing.T) {
+	baseURL, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: time.Second}
+
+	for _, body := range []map[string]any{
+		{"description": "migrate db", "tags": []string{"#Backend", "release-2.3"}},
+		{"description": "new logo", "tags": []string{"design"}},
+	} {
+		resp := doRequest(t, client, http.MethodPost, baseURL+"/todos", body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Create status = %v, want %v", resp.StatusCode, http.StatusCreated)
+		}
+	}
+
+	resp := doRequest(t, client, http.MethodGet, baseURL+"/read?tag=backend", nil)
+	defer resp.Body.Close()
+	var readResp TodosResponse
+	if err := json.NewDecoder(resp.Body).Decode(&readResp); err != nil {
+		t.Fatalf("failed to decode read response: %v", err)
+	}
+	if len(readResp.Todos) != 1 || readResp.Todos[0].Description != "migrate db" {
+		t.Errorf("expected only the backend item, got %+v", readResp.Todos)
+	}
+}
+
+func TestListRoutes(t *testing.T) {
+	baseURL, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: time.Second}
+
+	var created TodoResponse
+	for _, url := range []string{"/todos", "/lists/work/todos"} {
+		resp := doRequest(t, client, http.MethodPost, baseURL+url, map[string]string{"description": "standup"})
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Create in %s status = %v, want %v", url, resp.StatusCode, http.StatusCreated)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+			t.Fatalf("failed to decode create response: %v", err)
+		}
+		resp.Body.Close()
+	}
+	if created.Todo.List != "work" {
+		t.Errorf("expected item in list work, got %q", created.Todo.List)
+	}
+
+	resp := doRequest(t, client, http.MethodPost, baseURL+"/lists/work/todos", map[string]string{"description": "standup"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Duplicate create status = %v, want %v", resp.StatusCode, http.StatusConflict)
+	}
+
+	resp = doRequest(t, client, http.MethodGet, baseURL+"/lists/work/todos", nil)
+	var readResp TodosResponse
+	if err := json.NewDecoder(resp.Body).Decode(&readResp); err != nil {
+		t.Fatalf("failed to decode read response: %v", err)
+	}
+	resp.Body.Close()
+	if len(readResp.Todos) != 1 || readResp.Todos[0].ID != created.Todo.ID {
+		t.Errorf("expected only the work item, got %+v", readResp.Todos)
+	}
+
+	resp = doRequest(t, client, http.MethodGet, baseURL+"/lists/home/todos/"+created.Todo.ID, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Get through another list status = %v, want %v", resp.StatusCode, http.StatusNotFound)
+	}
+
+	resp = doRequest(t, client, http.MethodPatch, baseURL+"/lists/work", RenameListRequest{Name: "office"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Rename status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	resp = doRequest(t, client, http.MethodGet, baseURL+"/lists", nil)
+	var listsResp ListsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&listsResp); err != nil {
+		t.Fatalf("failed to decode lists response: %v", err)
+	}
+	resp.Body.Close()
+	want := []todo.ListSummary{{Name: todo.DefaultList, Count: 1}, {Name: "office", Count: 1}}
+	if !slices.Equal(listsResp.Lists, want) {
+		t.Errorf("expected lists %+v, got %+v", want, listsResp.Lists)
+	}
+
+	resp = doRequest(t, client, http.MethodPatch, baseURL+"/lists/office/todos/"+created.Todo.ID,
+		PatchRequest{Field: todo.UpdateFieldList, NewValue: todo.DefaultList})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Move onto a taken description status = %v, want %v", resp.StatusCode, http.StatusConflict)
+	}
+
+	resp = doRequest(t, client, http.MethodDelete, baseURL+"/lists/office", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Delete list status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	resp = doRequest(t, client, http.MethodDelete, baseURL+"/lists/office", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Delete missing list status = %v, want %v", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestSubtasks(t *testing.T) {
+	baseURL, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: time.Second}
+
+	create := func(body map[string]string) todo.Item {
+		t.Helper()
+		resp := doRequest(t, client, http.MethodPost, baseURL+"/todos", body)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Create status = %v, want %v", resp.StatusCode, http.StatusCreated)
+		}
+		var created TodoResponse
+		if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+			t.Fatalf("failed to decode create response: %v", err)
+		}
+		return created.Todo
+	}
+
+	parent := create(map[string]string{"description": "release 2.3"})
+	child := create(map[string]string{"description": "tag repo", "parentID": parent.ID})
+
+	resp := doRequest(t, client, http.MethodPatch, baseURL+"/todos/"+parent.ID,
+		PatchRequest{Field: todo.UpdateFieldStatus, NewValue: todo.Completed})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Patch parent status = %v, want %v", resp.StatusCode, http.StatusConflict)
+	}
+
+	resp = doRequest(t, client, http.MethodPatch, baseURL+"/todos/"+child.ID,
+		PatchRequest{Field: todo.UpdateFieldStatus, NewValue: todo.Completed})
+	resp.Body.Close()
+
+	resp = doRequest(t, client, http.MethodGet, baseURL+"/todos/"+parent.ID, nil)
+	var got TodoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode get response: %v", err)
+	}
+	resp.Body.Close()
+	if got.Todo.Status != todo.Completed {
+		t.Errorf("expected parent to be completed with its subtask, got %q", got.Todo.Status)
+	}
+
+	resp = doRequest(t, client, http.MethodGet, baseURL+"/list", nil)
+	page, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("failed to read list page: %v", err)
+	}
+	if bytes.Count(page, []byte("<ul>")) != 2 {
+		t.Errorf("expected the subtask in a nested list, got:\n%s", page)
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	baseURL, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: time.Second}
+
+	var ids []string
+	for _, desc := range []string{"write changelog", "tag repo"} {
+		resp := doRequest(t, client, http.MethodPost, baseURL+"/todos", map[string]string{"description": desc})
+		var created TodoResponse
+		if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+			t.Fatalf("failed to decode create response: %v", err)
+		}
+		resp.Body.Close()
+		ids = append(ids, created.Todo.ID)
+	}
+	changelog, tagRepo := ids[0], ids[1]
+
+	resp := doRequest(t, client, http.MethodPost, baseURL+"/todos/"+tagRepo+"/dependencies", DependencyRequest{ID: changelog})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Add dependency status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	resp = doRequest(t, client, http.MethodPost, baseURL+"/todos/"+changelog+"/dependencies", DependencyRequest{ID: tagRepo})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Add cyclic dependency status = %v, want %v", resp.StatusCode, http.StatusConflict)
+	}
+
+	complete := PatchRequest{Field: todo.UpdateFieldStatus, NewValue: todo.Completed}
+	resp = doRequest(t, client, http.MethodPatch, baseURL+"/todos/"+tagRepo, complete)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Complete blocked item status = %v, want %v", resp.StatusCode, http.StatusConflict)
+	}
+
+	resp = doRequest(t, client, http.MethodPatch, baseURL+"/update", UpdateRequest{ID: tagRepo, Field: todo.UpdateFieldStatus, NewValue: todo.Completed})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Legacy complete blocked item status = %v, want %v", resp.StatusCode, http.StatusConflict)
+	}
+
+	for path, want := range map[string]string{"/todos/blocked": tagRepo, "/todos/ready": changelog} {
+		resp = doRequest(t, client, http.MethodGet, baseURL+path, nil)
+		var listed TodosResponse
+		if err := json.NewDecoder(resp.Body).Decode(&listed); err != nil {
+			t.Fatalf("failed to decode %s response: %v", path, err)
+		}
+		resp.Body.Close()
+		if len(listed.Todos) != 1 || listed.Todos[0].ID != want {
+			t.Errorf("%s: expected only %s, got %+v", path, want, listed.Todos)
+		}
+	}
+
+	resp = doRequest(t, client, http.MethodPatch, baseURL+"/todos/"+changelog, complete)
+	resp.Body.Close()
+	resp = doRequest(t, client, http.MethodPatch, baseURL+"/todos/"+tagRepo, complete)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Complete unblocked item status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	baseURL, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: time.Second}
+
+	resp := doRequest(t, client, http.MethodPost, baseURL+"/todos", map[string]string{"description": "renew passport"})
+	var created TodoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode create response: %v", err)
+	}
+	resp.Body.Close()
+	id := created.Todo.ID
+
+	resp = doRequestAs(t, client, "alice", http.MethodPatch, baseURL+"/todos/"+id, PatchRequest{Field: todo.UpdateFieldStatus, NewValue: todo.Started})
+	var patched TodoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&patched); err != nil {
+		t.Fatalf("failed to decode patch response: %v", err)
+	}
+	resp.Body.Close()
+
+	resp = doRequest(t, client, http.MethodDelete, baseURL+"/todos/"+id, nil)
+	resp.Body.Close()
+	resp = doRequest(t, client, http.MethodDelete, baseURL+"/trash/"+id, nil)
+	resp.Body.Close()
+
+	resp = doRequest(t, client, http.MethodGet, baseURL+"/todos/"+id+"/history", nil)
+	var history HistoryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
+		t.Fatalf("failed to decode history response: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("History status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	var ops []string
+	for _, event := range history.Events {
+		ops = append(ops, event.Op)
+	}
+	if want := []string{storage.JournalAdd, storage.JournalUpdate, storage.JournalUpdate, storage.JournalRemove}; !slices.Equal(ops, want) {
+		t.Fatalf("expected events %v, got %v", want, ops)
+	}
+
+	started := history.Events[1]
+	if started.Actor != "alice" || started.TraceID != patched.TraceID {
+		t.Errorf("expected update by alice in trace %s, got %q in %s", patched.TraceID, started.Actor, started.TraceID)
+	}
+	if len(started.Changes) == 0 || started.Changes[0] != (storage.Change{Field: "Status", Old: todo.NotStarted, New: todo.Started}) {
+		t.Errorf("expected a status change, got %+v", started.Changes)
+	}
+	if trashed := history.Events[2]; len(trashed.Changes) != 1 || trashed.Changes[0].Field != "DeletedAt" {
+		t.Errorf("expected the removal to move the todo to the trash, got %+v", trashed.Changes)
+	}
+
+	resp = doRequest(t, client, http.MethodGet, baseURL+"/todos/missing/history", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("History of unknown item status = %v, want %v", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestUndoAndRedo(t *testing.T) {
+	baseURL, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: time.Second}
+
+	resp := doRequestAs(t, client, "alice", http.MethodPost, baseURL+"/todos", map[string]string{"description": "renew passport"})
+	var created TodoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode create response: %v", err)
+	}
+	resp.Body.Close()
+	id := created.Todo.ID
+
+	resp = doRequestAs(t, client, "alice", http.MethodDelete, baseURL+"/todos/"+id, nil)
+	resp.Body.Close()
+
+	resp = doRequestAs(t, client, "bob", http.MethodPost, baseURL+"/undo", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Undo with nothing to undo status = %v, want %v", resp.StatusCode, http.StatusConflict)
+	}
+
+	resp = doRequestAs(t, client, "alice", http.MethodPost, baseURL+"/undo", nil)
+	var undone UndoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&undone); err != nil {
+		t.Fatalf("failed to decode undo response: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || undone.Operations != 1 {
+		t.Fatalf("Undo status = %v with %d operations, want %v with 1", resp.StatusCode, undone.Operations, http.StatusOK)
+	}
+
+	resp = doRequest(t, client, http.MethodGet, baseURL+"/todos/"+id, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Get restored todo status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	resp = doRequestAs(t, client, "alice", http.MethodPost, baseURL+"/redo?count=2", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Redo status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	resp = doRequest(t, client, http.MethodGet, baseURL+"/todos/"+id, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Get removed todo status = %v, want %v", resp.StatusCode, http.StatusNotFound)
+	}
+
+	resp = doRequestAs(t, client, "alice", http.MethodPost, baseURL+"/undo?count=zero", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Undo with invalid count status = %v, want %v", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	resp = doRequest(t, client, http.MethodPost, baseURL+"/undo", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Undo without an actor status = %v, want %v", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	resp = doRequestAs(t, client, maintenanceActor, http.MethodPost, baseURL+"/undo", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Undo as the maintenance actor status = %v, want %v", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestTrash(t *testing.T) {
+	baseURL, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: time.Second}
+
+	var ids []string
+	for _, desc := range []string{"renew passport", "book flights"} {
+		resp := doRequest(t, client, http.MethodPost, baseURL+"/todos", map[string]string{"description": desc})
+		var created TodoResponse
+		if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+			t.Fatalf("failed to decode create response: %v", err)
+		}
+		resp.Body.Close()
+		ids = append(ids, created.Todo.ID)
+
+		resp = doRequest(t, client, http.MethodDelete, baseURL+"/todos/"+created.Todo.ID, nil)
+		resp.Body.Close()
+	}
+	passport, flights := ids[0], ids[1]
+
+	resp := doRequest(t, client, http.MethodGet, baseURL+"/trash", nil)
+	var trash TodosResponse
+	if err := json.NewDecoder(resp.Body).Decode(&trash); err != nil {
+		t.Fatalf("failed to decode trash response: %v", err)
+	}
+	resp.Body.Close()
+	if len(trash.Todos) != 2 {
+		t.Fatalf("expected 2 todos in the trash, got %+v", trash.Todos)
+	}
+
+	resp = doRequest(t, client, http.MethodGet, baseURL+"/todos/"+passport, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Get trashed todo status = %v, want %v", resp.StatusCode, http.StatusNotFound)
+	}
+
+	resp = doRequest(t, client, http.MethodPost, baseURL+"/trash/"+passport+"/restore", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Restore status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	resp = doRequest(t, client, http.MethodDelete, baseURL+"/trash/"+passport, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Purge live todo status = %v, want %v", resp.StatusCode, http.StatusConflict)
+	}
+
+	resp = doRequest(t, client, http.MethodDelete, baseURL+"/trash", nil)
+	var purged PurgeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&purged); err != nil {
+		t.Fatalf("failed to decode purge response: %v", err)
+	}
+	resp.Body.Close()
+	if purged.Purged != 1 {
+		t.Errorf("expected 1 todo to be purged, got %d", purged.Purged)
+	}
+
+	resp = doRequest(t, client, http.MethodPost, baseURL+"/trash/"+flights+"/restore", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Restore purged todo status = %v, want %v", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestBackgroundPurge(t *testing.T) {
+	now := time.Now()
+	store := storage.NewMemoryStore(
+		todo.Item{ID: "1", Description: "old", Status: todo.NotStarted, DeletedAt: now.Add(-48 * time.Hour)},
+		todo.Item{ID: "2", Description: "recent", Status: todo.NotStarted, DeletedAt: now.Add(-time.Hour)},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		purgeTrash(ctx, store, 24*time.Hour, time.Hour)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// The first purge runs straight away, without waiting for the interval.
+	deadline := time.Now().Add(time.Second)
+	for {
+		todos, err := store.LoadTodos(context.Background())
+		if err != nil {
+			t.Fatalf("LoadTodos failed: %v", err)
+		}
+		if len(todos) == 1 && todos[0].ID == "2" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected only the recent todo to remain, got %+v", todos)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBackgroundArchive(t *testing.T) {
+	now := time.Now()
+	store := storage.NewMemoryStore(
+		todo.Item{ID: "1", Description: "old", Status: todo.Completed, CompletedAt: now.Add(-48 * time.Hour)},
+		todo.Item{ID: "2", Description: "recent", Status: todo.Completed, CompletedAt: now.Add(-time.Hour)},
+		todo.Item{ID: "3", Description: "open", Status: todo.NotStarted},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		archiveCompleted(ctx, store, 24*time.Hour, time.Hour)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		todos, err := store.LoadTodos(context.Background())
+		if err != nil {
+			t.Fatalf("LoadTodos failed: %v", err)
+		}
+		if todo.IsArchived(todos[0]) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the old todo to be archived, got %+v", todos)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start listener: %v", err)
+	}
+	server := &http.Server{Handler: TraceMiddleware((&App{Store: store}).Routes())}
+	go server.Serve(listener)
+	defer server.Shutdown(context.Background())
+	baseURL := "http://" + listener.Addr().String()
+	client := &http.Client{Timeout: time.Second}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"default", "", []string{"recent", "open"}},
+		{"include archived", "?include=archived", []string{"old", "recent", "open"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doRequest(t, client, http.MethodGet, baseURL+"/todos"+tt.query, nil)
+			defer resp.Body.Close()
+
+			var listed TodosResponse
+			if err := json.NewDecoder(resp.Body).Decode(&listed); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			var got []string
+			for _, item := range listed.Todos {
+				got = append(got, item.Description)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	resp := doRequest(t, client, http.MethodGet, baseURL+"/todos?include=deleted", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status %d for an unknown include, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	resp = doRequest(t, client, http.MethodGet, baseURL+"/todos/1", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected archived todos to stay reachable by ID, got status %d", resp.StatusCode)
+	}
+}
+
+func TestReadPages(t *testing.T) {
+	baseURL, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: time.Second}
+
+	for _, desc := range []string{"task a", "task b", "task c", "chore d", "task e"} {
+		resp := doRequest(t, client, http.MethodPost, baseURL+"/todos", map[string]string{"description": desc})
+		resp.Body.Close()
+	}
+
+	var got []string
+	url := baseURL + "/todos?text=task&sort=-description&limit=2"
+	for pages := 0; url != ""; pages++ {
+		if pages > 3 {
+			t.Fatalf("expected the pages to end, got %v so far", got)
+		}
+		resp := doRequest(t, client, http.MethodGet, url, nil)
+		var page TodoPageResponse
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			t.Fatalf("failed to decode read response: %v", err)
+		}
+		resp.Body.Close()
+		if page.Total != 4 {
+			t.Errorf("expected a total of 4, got %d", page.Total)
+		}
+		for _, item := range page.Todos {
+			got = append(got, item.Description)
+		}
+
+		url = ""
+		if page.NextCursor != "" {
+			url = baseURL + "/todos?text=task&sort=-description&limit=2&cursor=" + page.NextCursor
+		}
+	}
+
+	want := []string{"task e", "task c", "task b", "task a"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	for _, query := range []string{"limit=ten", "offset=-1", "status=blocked", "cursor=bogus"} {
+		resp := doRequest(t, client, http.MethodGet, baseURL+"/todos?"+query, nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, resp.StatusCode)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	baseURL, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: time.Second}
+
+	for _, desc := range []string{"deploy staging", "deploy prod", "renew passport"} {
+		resp := doRequest(t, client, http.MethodPost, baseURL+"/todos", map[string]string{"description": desc})
+		resp.Body.Close()
+	}
+
+	resp := doRequest(t, client, http.MethodGet, baseURL+"/todos/search?q=Deploy+pro", nil)
+	var found SearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&found); err != nil {
+		t.Fatalf("failed to decode search response: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(found.Matches) != 1 || found.Matches[0].Item.Description != "deploy prod" {
+		t.Errorf("expected only deploy prod, got %d %+v", resp.StatusCode, found.Matches)
+	}
+
+	for _, query := range []string{"q=", "q=deploy&include=deleted"} {
+		resp := doRequest(t, client, http.MethodGet, baseURL+"/todos/search?"+query, nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, resp.StatusCode)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	baseURL, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: time.Second}
+
+	resp := doRequest(t, client, http.MethodPost, baseURL+"/todos:batch", BatchRequest{Operations: []todostore.Operation{
+		{Op: todostore.BatchCreate, Item: todo.Item{Description: "write report"}},
+		{Op: todostore.BatchCreate, Item: todo.Item{Description: "file taxes"}},
+		{Op: todostore.BatchUpdate, Description: "write report", Field: todo.UpdateFieldStatus, NewValue: todo.Completed},
+	}})
+	var applied BatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&applied); err != nil {
+		t.Fatalf("failed to decode batch response: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(applied.Results) != 3 || applied.Results[2].Item.Status != todo.Completed {
+		t.Fatalf("expected three applied operations, got %d %+v", resp.StatusCode, applied)
+	}
+
+	resp = doRequest(t, client, http.MethodPost, baseURL+"/todos:batch", BatchRequest{Operations: []todostore.Operation{
+		{Op: todostore.BatchDelete, ID: applied.Results[1].Item.ID},
+		{Op: todostore.BatchDelete, Description: "walk dog"},
+	}})
+	var failed BatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&failed); err != nil {
+		t.Fatalf("failed to decode batch response: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || failed.Error == "" {
+		t.Errorf("expected status %d with an error, got %d %+v", http.StatusNotFound, resp.StatusCode, failed)
+	}
+	if len(failed.Results) != 2 || failed.Results[0].Status != todostore.StatusAborted || failed.Results[1].Status != todostore.StatusFailed {
+		t.Errorf("expected the first operation aborted and the second failed, got %+v", failed.Results)
+	}
+
+	resp = doRequest(t, client, http.MethodGet, baseURL+"/todos/"+applied.Results[1].Item.ID, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the failed batch to leave file taxes alone, got status %d", resp.StatusCode)
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	store := storage.NewMemoryStore(todo.Item{ID: "1", Description: "write report", Status: todo.NotStarted})
+	ctx := context.WithValue(context.Background(), traceIDKey, "trace-1")
+
+	tests := []struct {
+		name   string
+		lines  string
+		status string
+		count  int
+	}{
+		{"invalid line", "-add 'book flights'\n-find \"write report\"\n", todo.NotStarted, 1},
+		{"failed operation", "-add 'book flights'\n-remove 'walk dog'\n", todo.NotStarted, 1},
+		{"applied", "# weekly chores\n-add 'book flights' -priority high\n\n-find \"write report\" -update-status completed\n", todo.Completed, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "batch.txt")
+			if err := os.WriteFile(path, []byte(tt.lines), 0644); err != nil {
+				t.Fatalf("failed to write batch: %v", err)
+			}
+
+			runBatch(ctx, path, "", store)
+
+			todos, err := store.LoadTodos(ctx)
+			if err != nil {
+				t.Fatalf("LoadTodos failed: %v", err)
+			}
+			if len(todos) != tt.count || todos[0].Status != tt.status {
+				t.Errorf("expected %d todos with write report %s, got %+v", tt.count, tt.status, todos)
+			}
+		})
+	}
+}
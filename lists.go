@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"todo-app/todo"
+	"todo-app/todostore"
+)
+
+type ListsResponse struct {
+	TraceID string
+	Lists   []todo.ListSummary
+}
+
+type RenameListRequest struct {
+	Name string
+}
+
+func (a *App) ListsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+
+	lists, err := todostore.Lists(ctx, a.Store)
+	if err != nil {
+		writeStoreError(w, err, "failed to fetch lists", traceID)
+		slog.ErrorContext(ctx, "failed to fetch lists", "traceID", traceID, "error", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ListsResponse{TraceID: traceID, Lists: lists})
+}
+
+func (a *App) RenameListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+	name := r.PathValue("name")
+
+	var request RenameListRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON", traceID)
+		slog.ErrorContext(ctx, "failed to decode request", "traceID", traceID, "error", err)
+		return
+	}
+
+	slog.InfoContext(ctx, "Renaming list", "list", name, "newName", request.Name, "traceID", traceID)
+
+	if err := todostore.RenameList(ctx, name, request.Name, a.Store); err != nil {
+		writeStoreError(w, err, "failed to rename list", traceID)
+		slog.ErrorContext(ctx, "failed to rename list", "list", name, "traceID", traceID, "error", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "List renamed",
+		"traceID": traceID,
+	})
+}
+
+func (a *App) DeleteListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+	name := r.PathValue("name")
+
+	slog.InfoContext(ctx, "Deleting list", "list", name, "traceID", traceID)
+
+	if err := todostore.DeleteList(ctx, name, a.Store); err != nil {
+		writeStoreError(w, err, "failed to delete list", traceID)
+		slog.ErrorContext(ctx, "failed to delete list", "list", name, "traceID", traceID, "error", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "List deleted",
+		"traceID": traceID,
+	})
+}
+
+// listKey holds the list named in a /lists/{name}/todos/{id} path.
+const listKey contextKey = "list"
+
+// inList serves /lists/{name}/todos/{id} with a /todos/{id} handler, which
+// then only acts on the item if it belongs to the list in the path.
+func (a *App) inList(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), listKey, r.PathValue("name"))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
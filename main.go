@@ -2,11 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"os/user"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -21,33 +26,276 @@ type contextKey string
 
 const traceIDKey contextKey = "traceID"
 
-func startCLI(view bool, add, find, updateStatus, updateDesc, remove string) {
+type cliOptions struct {
+	view         bool
+	add          string
+	find         string
+	updateStatus string
+	updateDesc   string
+	remove       string
+	due          string
+	overdue      bool
+	priority     string
+	sort         string
+	tags         []string
+	untags       []string
+	list         string
+	lists        bool
+	renameList   string
+	deleteList   string
+	moveTo       string
+	parent       string
+	dependsOn    string
+	noDependsOn  string
+	blocked      bool
+	ready        bool
+	repeat       string
+	notes        string
+	history      string
+	undo         int
+	redo         int
+	trash        bool
+	restore      string
+	purge        string
+	emptyTrash   bool
+	archived     bool
+	archive      bool
+	archiveAfter time.Duration
+	status       string
+	text         string
+	limit        int
+	offset       int
+	cursor       string
+	search       string
+	batch        string
+}
+
+// stringList collects the values of a flag that may be repeated.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// countFlag is a number that may be given as a bare flag meaning one, so both
+// -undo and -undo=3 work.
+type countFlag int
+
+func (c *countFlag) String() string {
+	return strconv.Itoa(int(*c))
+}
+
+func (c *countFlag) Set(value string) error {
+	if value == "true" {
+		*c = 1
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return fmt.Errorf("%q is not a count", value)
+	}
+	*c = countFlag(n)
+	return nil
+}
+
+func (c *countFlag) IsBoolFlag() bool {
+	return true
+}
+
+func startCLI(store storage.Store, opts cliOptions) {
 	traceID := uuid.New().String()
 	ctx := context.WithValue(context.Background(), traceIDKey, traceID)
-	fs := storage.NewFileStore("todos.json")
-	defer fs.Close()
+	ctx = storage.WithActor(storage.WithTraceID(ctx, traceID), cliActor())
 
 	switch {
-	case view:
-		todostore.GetAll(ctx, fs)
-	case add != "":
-		slog.InfoContext(ctx, "Creating todo", "desc", add, "traceID", traceID)
-		if err := todostore.Add(ctx, add, fs); err != nil {
+	case opts.batch != "":
+		runBatch(ctx, opts.batch, opts.list, store)
+	case opts.view:
+		q := todostore.Query{
+			Status:   opts.status,
+			Text:     opts.text,
+			Tags:     opts.tags,
+			List:     opts.list,
+			Archived: opts.archived,
+			Sort:     todostore.SortOrder(opts.sort),
+			Limit:    opts.limit,
+			Offset:   opts.offset,
+			Cursor:   opts.cursor,
+		}
+		if err := todostore.GetAll(ctx, q, store); err != nil {
+			slog.ErrorContext(ctx, "failed to fetch todo items", "traceID", traceID, "error", err)
+		}
+	case opts.overdue:
+		printDue(ctx, opts.list, store)
+	case opts.blocked:
+		printItems(ctx, "blocked", opts.list, todostore.Blocked, store)
+	case opts.ready:
+		printItems(ctx, "ready", opts.list, todostore.Ready, store)
+	case opts.lists:
+		printLists(ctx, store)
+	case opts.history != "":
+		printHistory(ctx, opts.list, opts.history, store)
+	case opts.search != "":
+		printSearch(ctx, opts.search, opts.list, opts.archived, store)
+	case opts.trash:
+		printItems(ctx, "trashed", opts.list, todostore.Trash, store)
+	case opts.restore != "":
+		slog.InfoContext(ctx, "Restoring todo", "desc", opts.restore, "traceID", traceID)
+		if err := todostore.Restore(ctx, opts.list, opts.restore, store); err != nil {
+			slog.ErrorContext(ctx, "failed to restore item", "traceID", traceID, "error", err)
+		}
+	case opts.purge != "":
+		slog.InfoContext(ctx, "Purging todo", "desc", opts.purge, "traceID", traceID)
+		if err := todostore.Purge(ctx, opts.list, opts.purge, store); err != nil {
+			slog.ErrorContext(ctx, "failed to purge item", "traceID", traceID, "error", err)
+		}
+	case opts.emptyTrash:
+		slog.InfoContext(ctx, "Emptying trash", "traceID", traceID)
+		purged, err := todostore.PurgeTrash(ctx, todo.Now(), store)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to empty trash", "traceID", traceID, "error", err)
+		}
+		fmt.Printf("Purged %d item(s)\n", purged)
+	case opts.archive && opts.archiveAfter <= 0:
+		fmt.Println("Archiving is disabled by -archive-after 0")
+	case opts.archive:
+		slog.InfoContext(ctx, "Archiving completed todos", "age", opts.archiveAfter, "traceID", traceID)
+		archived, err := todostore.ArchiveCompleted(ctx, todo.Now().Add(-opts.archiveAfter), store)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to archive items", "traceID", traceID, "error", err)
+		}
+		fmt.Printf("Archived %d item(s)\n", archived)
+	case opts.undo > 0:
+		slog.InfoContext(ctx, "Undoing operations", "count", opts.undo, "traceID", traceID)
+		done, err := todostore.Undo(ctx, opts.undo, store)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to undo", "traceID", traceID, "error", err)
+		}
+		fmt.Printf("Undid %d operation(s)\n", done)
+	case opts.redo > 0:
+		slog.InfoContext(ctx, "Redoing operations", "count", opts.redo, "traceID", traceID)
+		done, err := todostore.Redo(ctx, opts.redo, store)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to redo", "traceID", traceID, "error", err)
+		}
+		fmt.Printf("Redid %d operation(s)\n", done)
+	case opts.renameList != "":
+		slog.InfoContext(ctx, "Renaming list", "list", opts.list, "newName", opts.renameList, "traceID", traceID)
+		if err := todostore.RenameList(ctx, opts.list, opts.renameList, store); err != nil {
+			slog.ErrorContext(ctx, "failed to rename list", "traceID", traceID, "error", err)
+		}
+	case opts.deleteList != "":
+		slog.InfoContext(ctx, "Deleting list", "list", opts.deleteList, "traceID", traceID)
+		if err := todostore.DeleteList(ctx, opts.deleteList, store); err != nil {
+			slog.ErrorContext(ctx, "failed to delete list", "traceID", traceID, "error", err)
+		}
+	case opts.add != "":
+		slog.InfoContext(ctx, "Creating todo", "desc", opts.add, "traceID", traceID)
+		draft := todo.Item{Description: opts.add, Priority: opts.priority, Tags: opts.tags, List: opts.list, Recurrence: opts.repeat, Notes: opts.notes}
+		if opts.due != "" {
+			due, err := todo.ParseDueDate(opts.due)
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to create item", "traceID", traceID, "error", err)
+				return
+			}
+			draft.Due = due
+		}
+		var item todo.Item
+		var err error
+		if opts.parent != "" {
+			item, err = todostore.AddSubtask(ctx, opts.list, opts.parent, draft, store)
+		} else {
+			item, err = todostore.AddItem(ctx, draft, store)
+		}
+		if err != nil {
 			slog.ErrorContext(ctx, "failed to create item", "traceID", traceID, "error", err)
+			return
+		}
+		slog.InfoContext(ctx, "Created todo", "id", item.ID, "traceID", traceID)
+	case opts.remove != "":
+		desc, ok := resolveDesc(ctx, opts.list, opts.remove, store)
+		if !ok {
+			return
 		}
-	case remove != "":
-		slog.InfoContext(ctx, "Deleting todo", "desc", remove, "traceID", traceID)
-		if err := todostore.Remove(ctx, remove, fs); err != nil {
+		slog.InfoContext(ctx, "Deleting todo", "desc", desc, "traceID", traceID)
+		if err := todostore.Remove(ctx, opts.list, desc, store); err != nil {
 			slog.ErrorContext(ctx, "failed to delete item", "traceID", traceID, "error", err)
 		}
-	case find != "" && updateStatus != "":
-		slog.InfoContext(ctx, "Updating todo", "desc", updateStatus)
-		if err := todostore.Update(ctx, find, todo.UpdateFieldStatus, updateStatus, fs); err != nil {
+	case opts.find != "":
+		desc, ok := resolveDesc(ctx, opts.list, opts.find, store)
+		if !ok {
+			return
+		}
+		opts.find = desc
+		updateFound(ctx, opts, store)
+	default:
+		slog.InfoContext(ctx, "No CLI action specified")
+	}
+}
+
+// updateFound makes the change the options ask for to the item opts.find.
+func updateFound(ctx context.Context, opts cliOptions, store storage.Store) {
+	traceID := ctx.Value(traceIDKey).(string)
+
+	switch {
+	case opts.updateStatus != "":
+		slog.InfoContext(ctx, "Updating todo", "desc", opts.updateStatus)
+		if err := todostore.Update(ctx, opts.list, opts.find, todo.UpdateFieldStatus, opts.updateStatus, store); err != nil {
+			slog.ErrorContext(ctx, "failed to update item", "traceID", traceID, "error", err)
+		}
+	case opts.updateDesc != "":
+		slog.InfoContext(ctx, "Updating todo", "desc", opts.updateStatus)
+		if err := todostore.Update(ctx, opts.list, opts.find, todo.UpdateFieldDescription, opts.updateDesc, store); err != nil {
+			slog.ErrorContext(ctx, "failed to update item", "traceID", traceID, "error", err)
+		}
+	case opts.due != "":
+		slog.InfoContext(ctx, "Updating todo", "desc", opts.find, "due", opts.due)
+		if err := todostore.Update(ctx, opts.list, opts.find, todo.UpdateFieldDue, opts.due, store); err != nil {
+			slog.ErrorContext(ctx, "failed to update item", "traceID", traceID, "error", err)
+		}
+	case opts.priority != "":
+		slog.InfoContext(ctx, "Updating todo", "desc", opts.find, "priority", opts.priority)
+		if err := todostore.Update(ctx, opts.list, opts.find, todo.UpdateFieldPriority, opts.priority, store); err != nil {
 			slog.ErrorContext(ctx, "failed to update item", "traceID", traceID, "error", err)
 		}
-	case find != "" && updateDesc != "":
-		slog.InfoContext(ctx, "Updating todo", "desc", updateStatus)
-		if err := todostore.Update(ctx, find, todo.UpdateFieldDescription, updateDesc, fs); err != nil {
+	case len(opts.tags) > 0:
+		slog.InfoContext(ctx, "Tagging todo", "desc", opts.find, "tags", opts.tags)
+		if err := todostore.AddTags(ctx, opts.list, opts.find, opts.tags, store); err != nil {
+			slog.ErrorContext(ctx, "failed to update item", "traceID", traceID, "error", err)
+		}
+	case len(opts.untags) > 0:
+		slog.InfoContext(ctx, "Untagging todo", "desc", opts.find, "tags", opts.untags)
+		if err := todostore.RemoveTags(ctx, opts.list, opts.find, opts.untags, store); err != nil {
+			slog.ErrorContext(ctx, "failed to update item", "traceID", traceID, "error", err)
+		}
+	case opts.moveTo != "":
+		slog.InfoContext(ctx, "Moving todo", "desc", opts.find, "list", opts.moveTo)
+		if err := todostore.Update(ctx, opts.list, opts.find, todo.UpdateFieldList, opts.moveTo, store); err != nil {
+			slog.ErrorContext(ctx, "failed to update item", "traceID", traceID, "error", err)
+		}
+	case opts.repeat != "":
+		slog.InfoContext(ctx, "Updating todo", "desc", opts.find, "recurrence", opts.repeat)
+		if err := todostore.Update(ctx, opts.list, opts.find, todo.UpdateFieldRecurrence, opts.repeat, store); err != nil {
+			slog.ErrorContext(ctx, "failed to update item", "traceID", traceID, "error", err)
+		}
+	case opts.notes != "":
+		slog.InfoContext(ctx, "Updating todo", "desc", opts.find, "notes", opts.notes)
+		if err := todostore.Update(ctx, opts.list, opts.find, todo.UpdateFieldNotes, opts.notes, store); err != nil {
+			slog.ErrorContext(ctx, "failed to update item", "traceID", traceID, "error", err)
+		}
+	case opts.dependsOn != "":
+		slog.InfoContext(ctx, "Adding dependency", "desc", opts.find, "dependsOn", opts.dependsOn)
+		if err := todostore.AddDependency(ctx, opts.list, opts.find, opts.dependsOn, store); err != nil {
+			slog.ErrorContext(ctx, "failed to update item", "traceID", traceID, "error", err)
+		}
+	case opts.noDependsOn != "":
+		slog.InfoContext(ctx, "Removing dependency", "desc", opts.find, "dependsOn", opts.noDependsOn)
+		if err := todostore.RemoveDependency(ctx, opts.list, opts.find, opts.noDependsOn, store); err != nil {
 			slog.ErrorContext(ctx, "failed to update item", "traceID", traceID, "error", err)
 		}
 	default:
@@ -55,22 +303,162 @@ func startCLI(view bool, add, find, updateStatus, updateDesc, remove string) {
 	}
 }
 
-func startServer() {
-	fs := storage.NewFileStore("todos.json")
-	defer fs.Close()
-	app := &App{FS: fs}
+// printDue prints overdue items and those due today, from every list when
+// list is empty.
+func printDue(ctx context.Context, list string, store storage.Store) {
+	traceID := ctx.Value(traceIDKey).(string)
+	now := time.Now()
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/create", app.CreateHandler)
-	mux.HandleFunc("/read", app.ReadHandler)
-	mux.HandleFunc("/update", app.UpdateHandler)
-	mux.HandleFunc("/delete", app.DeleteHandler)
-	mux.HandleFunc("/list", app.ListPageHandler)
-	mux.Handle("/about/", http.StripPrefix("/about/", http.FileServer(http.Dir("static"))))
+	overdue, err := todostore.Overdue(ctx, now, store)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to fetch overdue items", "traceID", traceID, "error", err)
+		return
+	}
+	dueToday, err := todostore.DueToday(ctx, now, store)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to fetch items due today", "traceID", traceID, "error", err)
+		return
+	}
+
+	if list != "" {
+		overdue = todo.FilterByList(overdue, list)
+		dueToday = todo.FilterByList(dueToday, list)
+	}
+
+	fmt.Println("Overdue:")
+	todo.PrintTodos(overdue)
+	fmt.Println("Due today:")
+	todo.PrintTodos(dueToday)
+}
+
+// printItems prints the result of a query such as todostore.Blocked, from
+// every list when list is empty.
+func printItems(ctx context.Context, name, list string, query func(context.Context, storage.Store) ([]todo.Item, error), store storage.Store) {
+	traceID := ctx.Value(traceIDKey).(string)
+
+	todos, err := query(ctx, store)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to fetch "+name+" items", "traceID", traceID, "error", err)
+		return
+	}
+	if list != "" {
+		todos = todo.FilterByList(todos, list)
+	}
+
+	todo.PrintTodos(todos)
+}
+
+func printLists(ctx context.Context, store storage.Store) {
+	traceID := ctx.Value(traceIDKey).(string)
+
+	lists, err := todostore.Lists(ctx, store)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to fetch lists", "traceID", traceID, "error", err)
+		return
+	}
+
+	for _, list := range lists {
+		fmt.Printf("%s: %d/%d completed\n", list.Name, list.Completed, list.Count)
+	}
+}
+
+// printHistory prints the audit trail of an item, one line per event followed
+// by the fields it changed.
+func printHistory(ctx context.Context, list, desc string, store storage.Store) {
+	traceID := ctx.Value(traceIDKey).(string)
+
+	events, err := todostore.History(ctx, list, desc, store)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to fetch history", "traceID", traceID, "error", err)
+		return
+	}
+
+	for _, event := range events {
+		actor := event.Actor
+		if actor == "" {
+			actor = "unknown"
+		}
+		fmt.Printf("%s %s by %s (trace %s)\n", event.Time.Format("2006-01-02 15:04:05"), event.Op, actor, event.TraceID)
+		for _, change := range event.Changes {
+			fmt.Printf("  %s: %q -> %q\n", change.Field, change.Old, change.New)
+		}
+	}
+}
+
+// resolveDesc returns the description of the item that desc most likely
+// means, telling the user when it is not desc itself. When several items
+// could be meant it prints them as suggestions instead.
+func resolveDesc(ctx context.Context, list, desc string, store storage.Store) (string, bool) {
+	traceID := ctx.Value(traceIDKey).(string)
+
+	item, err := todostore.FindFuzzy(ctx, list, desc, store)
+	var ambiguous *todo.AmbiguousMatchError
+	switch {
+	case errors.As(err, &ambiguous):
+		quoted := make([]string, len(ambiguous.Candidates))
+		for i, candidate := range ambiguous.Candidates {
+			quoted[i] = strconv.Quote(candidate)
+		}
+		fmt.Printf("No item is called %q. Did you mean %s?\n", desc, strings.Join(quoted, " or "))
+		return "", false
+	case err != nil:
+		slog.ErrorContext(ctx, "failed to find item", "desc", desc, "traceID", traceID, "error", err)
+		return "", false
+	}
+
+	if item.Description != strings.ToLower(desc) {
+		fmt.Printf("Using %q for %q\n", item.Description, desc)
+	}
+	return item.Description, true
+}
+
+// printSearch prints the items matching query, most relevant first, from
+// every list when list is empty.
+func printSearch(ctx context.Context, query, list string, archived bool, store storage.Store) {
+	traceID := ctx.Value(traceIDKey).(string)
+
+	matches, err := todostore.Search(ctx, query, archived, store)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to search todos", "traceID", traceID, "error", err)
+		return
+	}
+
+	todos := make([]todo.Item, len(matches))
+	for i, match := range matches {
+		todos[i] = match.Item
+	}
+	if list != "" {
+		todos = todo.FilterByList(todos, list)
+	}
+	todo.PrintFlat(todos)
+}
+
+// cliActor is the user that CLI changes are recorded as made by.
+func cliActor() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// startServer serves the API until interrupted. In the background, items
+// that have been in the trash for longer than retention are purged and items
+// completed more than archiveAfter ago are archived; zero disables either.
+func startServer(store storage.Store, retention, archiveAfter time.Duration) {
+	app := &App{Store: store}
+
+	maintenanceCtx, stopMaintenance := context.WithCancel(context.Background())
+	defer stopMaintenance()
+	if retention > 0 {
+		go purgeTrash(maintenanceCtx, store, retention, min(retention, maintenanceInterval))
+	}
+	if archiveAfter > 0 {
+		go archiveCompleted(maintenanceCtx, store, archiveAfter, maintenanceInterval)
+	}
 
 	server := &http.Server{
 		Addr:    ":8080",
-		Handler: TraceMiddleware(mux),
+		Handler: TraceMiddleware(app.Routes()),
 	}
 
 	stop := make(chan os.Signal, 1)
@@ -85,6 +473,7 @@ func startServer() {
 
 	<-stop
 	slog.Info("Shutting down server gracefully...")
+	stopMaintenance()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -96,20 +485,134 @@ func startServer() {
 	slog.Info("Server stopped")
 }
 
+func openStore(kind, dbPath string) (storage.Store, error) {
+	switch kind {
+	case "file":
+		return storage.NewFileStore("todos.json"), nil
+	case "journal":
+		return storage.NewJournalStore("todos.journal", storage.DefaultCompactEvery), nil
+	case "sqlite":
+		return storage.NewSQLiteStore(dbPath)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", kind)
+	}
+}
+
 func main() {
 	modeFlag := flag.String("mode", "cli", "Choose mode: cli or server")
+	storageFlag := flag.String("storage", "file", "Choose storage backend: file, journal or sqlite")
+	dbFlag := flag.String("db", "todos.db", "Path to the SQLite database when -storage sqlite is used")
 	viewFlag := flag.Bool("view", false, "View to-do list")
 	addFlag := flag.String("add", "", "Add a new to-do item")
 	findFlag := flag.String("find", "", "Find a to-do item by description")
 	updateStatusFlag := flag.String("update-status", "", "Update a to-do item status")
 	updateDescFlag := flag.String("update-description", "", "Update a to-do item description")
 	removeFlag := flag.String("remove", "", "Remove a to-do item")
+	dueFlag := flag.String("due", "", "Due date (YYYY-MM-DD or RFC 3339) for -add, or for the item given by -find")
+	overdueFlag := flag.Bool("overdue", false, "View overdue to-do items and those due today")
+	priorityFlag := flag.String("priority", "", "Priority (low, medium, high, urgent) for -add, or for the item given by -find")
+	sortFlag := flag.String("sort", "", "Sort order for -view: priority, due, created, updated, completed, description or status, prefixed by - to reverse it")
+	statusFilterFlag := flag.String("status", "", "Only show items with this status in -view")
+	textFlag := flag.String("text", "", "Only show items whose description contains this text in -view")
+	limitFlag := flag.Int("limit", 0, "Show at most this many items in -view; 0 shows all of them")
+	offsetFlag := flag.Int("offset", 0, "Skip this many items in -view")
+	cursorFlag := flag.String("cursor", "", "Continue -view from the page that printed this cursor")
+	batchFlag := flag.String("batch", "", "Apply the -add, -find and -remove commands in this file, one per line, all or nothing; - reads stdin")
+	searchFlag := flag.String("search", "", "Search descriptions and tags for words starting with these, most relevant first")
+	var tagFlags, untagFlags stringList
+	flag.Var(&tagFlags, "tag", "Tag for -add or the item given by -find, or tag to filter -view by (repeatable)")
+	flag.Var(&untagFlags, "untag", "Tag to remove from the item given by -find (repeatable)")
+	listFlag := flag.String("list", "", "List to work in; -view and -overdue show every list when it is not set")
+	listsFlag := flag.Bool("lists", false, "View the lists and how many of their items are completed")
+	renameListFlag := flag.String("rename-list", "", "New name for the list given by -list")
+	deleteListFlag := flag.String("delete-list", "", "Delete a list and all of its items")
+	moveToFlag := flag.String("move-to", "", "Move the item given by -find to another list")
+	parentFlag := flag.String("parent", "", "Description of the item that -add creates a subtask under")
+	dependsOnFlag := flag.String("depends-on", "", "Description of an item that the item given by -find must wait for")
+	noDependsOnFlag := flag.String("no-depends-on", "", "Description of a dependency to remove from the item given by -find")
+	blockedFlag := flag.Bool("blocked", false, "View items waiting on an unfinished dependency")
+	readyFlag := flag.Bool("ready", false, "View unfinished items whose dependencies are all completed")
+	notesFlag := flag.String("notes", "", "Notes for -add or the item given by -find, searched by -search")
+	repeatFlag := flag.String("repeat", "", "Recurrence for -add or the item given by -find: daily, weekly on mon,thu, monthly on 15, every 3 days or an RRULE")
+	historyFlag := flag.String("history", "", "View the change history of a to-do item by description")
+	var undoFlag, redoFlag countFlag
+	flag.Var(&undoFlag, "undo", "Undo your last change, or the last N with -undo=N")
+	flag.Var(&redoFlag, "redo", "Redo your last undone change, or the last N with -redo=N")
+	trashFlag := flag.Bool("trash", false, "View removed items that have not been purged yet")
+	restoreFlag := flag.String("restore", "", "Take a removed item out of the trash by description")
+	purgeFlag := flag.String("purge", "", "Permanently delete a removed item by description")
+	emptyTrashFlag := flag.Bool("empty-trash", false, "Permanently delete everything in the trash")
+	trashRetentionFlag := flag.Duration("trash-retention", DefaultTrashRetention, "How long the server keeps removed items before purging them; 0 keeps them forever")
+	archivedFlag := flag.Bool("archived", false, "Include archived items in -view and -search")
+	archiveCompletedFlag := flag.Bool("archive-completed", false, "Archive items completed at least -archive-after ago")
+	archiveAfterFlag := flag.Duration("archive-after", DefaultArchiveAfter, "How long after completion items are archived by the server and by -archive-completed; 0 never archives them")
+	blockStartFlag := flag.Bool("block-start", false, "Also refuse to start items with unfinished dependencies")
 
 	flag.Parse()
 
+	// A count after a bare -undo or -redo, as in -undo 3, ends flag parsing
+	// instead of setting the count.
+	if flag.NArg() > 0 {
+		slog.Error("Unexpected argument, give counts as -undo=N", "argument", flag.Arg(0))
+		os.Exit(2)
+	}
+
+	if *blockStartFlag {
+		todo.BlockedStatuses = append(todo.BlockedStatuses, todo.Started)
+	}
+
+	store, err := openStore(*storageFlag, *dbFlag)
+	if err != nil {
+		slog.Error("Failed to open storage", "storage", *storageFlag, "error", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
 	if *modeFlag == "server" {
-		startServer()
+		startServer(store, *trashRetentionFlag, *archiveAfterFlag)
 	} else {
-		startCLI(*viewFlag, *addFlag, *findFlag, *updateStatusFlag, *updateDescFlag, *removeFlag)
+		startCLI(store, cliOptions{
+			view:         *viewFlag,
+			add:          *addFlag,
+			find:         *findFlag,
+			updateStatus: *updateStatusFlag,
+			updateDesc:   *updateDescFlag,
+			remove:       *removeFlag,
+			due:          *dueFlag,
+			overdue:      *overdueFlag,
+			priority:     *priorityFlag,
+			sort:         *sortFlag,
+			tags:         tagFlags,
+			untags:       untagFlags,
+			list:         *listFlag,
+			lists:        *listsFlag,
+			renameList:   *renameListFlag,
+			deleteList:   *deleteListFlag,
+			moveTo:       *moveToFlag,
+			parent:       *parentFlag,
+			dependsOn:    *dependsOnFlag,
+			noDependsOn:  *noDependsOnFlag,
+			blocked:      *blockedFlag,
+			ready:        *readyFlag,
+			repeat:       *repeatFlag,
+			notes:        *notesFlag,
+			history:      *historyFlag,
+			undo:         int(undoFlag),
+			redo:         int(redoFlag),
+			trash:        *trashFlag,
+			restore:      *restoreFlag,
+			purge:        *purgeFlag,
+			emptyTrash:   *emptyTrashFlag,
+			archived:     *archivedFlag,
+			archive:      *archiveCompletedFlag,
+			archiveAfter: *archiveAfterFlag,
+			status:       *statusFilterFlag,
+			text:         *textFlag,
+			limit:        *limitFlag,
+			offset:       *offsetFlag,
+			cursor:       *cursorFlag,
+			search:       *searchFlag,
+			batch:        *batchFlag,
+		})
 	}
 }
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"todo-app/storage"
+	"todo-app/todo"
+	"todo-app/todostore"
+
+	"github.com/google/uuid"
+)
+
+// DefaultArchiveAfter is how long completed items stay in the default
+// listings before they are archived.
+const DefaultArchiveAfter = 7 * 24 * time.Hour
+
+// maintenanceInterval is how often the server looks for trash to purge and
+// completed items to archive.
+const maintenanceInterval = time.Hour
+
+// maintenanceActor is who background changes are recorded as made by, so
+// that they are never on a caller's undo stack.
+const maintenanceActor = "system"
+
+// every runs task straight away and then every interval until ctx is done.
+// Each run gets a trace ID of its own.
+func every(ctx context.Context, interval time.Duration, task func(ctx context.Context, traceID string)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		traceID := uuid.New().String()
+		runCtx := context.WithValue(ctx, traceIDKey, traceID)
+		task(storage.WithActor(storage.WithTraceID(runCtx, traceID), maintenanceActor), traceID)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeTrash purges items that have been in the trash for longer than
+// retention.
+func purgeTrash(ctx context.Context, store storage.Store, retention, interval time.Duration) {
+	every(ctx, interval, func(ctx context.Context, traceID string) {
+		purged, err := todostore.PurgeTrash(ctx, todo.Now().Add(-retention), store)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to purge trash", "traceID", traceID, "error", err)
+		} else if purged > 0 {
+			slog.InfoContext(ctx, "Purged trash", "count", purged, "retention", retention, "traceID", traceID)
+		}
+	})
+}
+
+// archiveCompleted archives items completed more than age ago.
+func archiveCompleted(ctx context.Context, store storage.Store, age, interval time.Duration) {
+	every(ctx, interval, func(ctx context.Context, traceID string) {
+		archived, err := todostore.ArchiveCompleted(ctx, todo.Now().Add(-age), store)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to archive completed items", "traceID", traceID, "error", err)
+		} else if archived > 0 {
+			slog.InfoContext(ctx, "Archived completed items", "count", archived, "age", age, "traceID", traceID)
+		}
+	})
+}
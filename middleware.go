@@ -3,17 +3,43 @@ package main
 import (
 	"context"
 	"log/slog"
+	"strings"
 
 	"github.com/google/uuid"
 	"net/http"
+
+	"todo-app/storage"
 )
 
+// actorHeader names the caller that changes are recorded as made by. There
+// is no authentication, so it is taken on trust, except that callers cannot
+// pass themselves off as the background maintenance.
+const actorHeader = "X-Actor"
+
 func TraceMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		traceID := uuid.New().String()
 		ctx := context.WithValue(r.Context(), traceIDKey, traceID)
+		actor := r.Header.Get(actorHeader)
+		if strings.EqualFold(actor, maintenanceActor) {
+			writeError(w, http.StatusBadRequest, actorHeader+" "+actor+" is reserved", traceID)
+			slog.WarnContext(ctx, "Reserved actor rejected", "actor", actor, "traceID", traceID)
+			return
+		}
+		ctx = storage.WithActor(storage.WithTraceID(ctx, traceID), actor)
 		logger := slog.Default().With("traceID", traceID)
 		logger.InfoContext(ctx, "Request received", "path", r.URL.Path)	
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
-}
\ No newline at end of file
+}
+
+// DeprecatedMiddleware marks responses from a legacy endpoint as deprecated
+// and points clients at the route that replaces it.
+func DeprecatedMiddleware(successor string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successor+">; rel=\"successor-version\"")
+		slog.WarnContext(r.Context(), "Deprecated endpoint called", "path", r.URL.Path, "successor", successor)
+		next.ServeHTTP(w, r)
+	})
+}
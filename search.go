@@ -0,0 +1,34 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+
+	"todo-app/storage"
+	"todo-app/todostore"
+)
+
+// SearchResponse lists the items found by a search, most relevant first.
+type SearchResponse struct {
+	TraceID string
+	Matches []storage.Match
+}
+
+func (a *App) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+
+	query := r.URL.Query()
+	archived, err := includesArchived(query)
+	var matches []storage.Match
+	if err == nil {
+		matches, err = todostore.Search(ctx, query.Get("q"), archived, a.Store)
+	}
+	if err != nil {
+		writeStoreError(w, err, "failed to search todos", traceID)
+		slog.ErrorContext(ctx, "failed to search todos", "traceID", traceID, "error", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SearchResponse{TraceID: traceID, Matches: matches})
+}
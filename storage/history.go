@@ -0,0 +1,302 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"todo-app/todo"
+)
+
+// Event is one entry of the audit history: a single item added, updated or
+// removed by one call to Update or SaveTodos. Op is JournalAdd,
+// JournalUpdate or JournalRemove. Before is missing for additions and After
+// for removals. Undoes and Redoes hold the Seq that starts the operation an
+// undo or redo reverted or re-applied.
+type Event struct {
+	Seq     int64
+	Time    time.Time
+	TraceID string `json:",omitempty"`
+	Actor   string `json:",omitempty"`
+	ItemID  string
+	Op      string
+	Changes []Change   `json:",omitempty"`
+	Before  *todo.Item `json:",omitempty"`
+	After   *todo.Item `json:",omitempty"`
+	Undoes  int64      `json:",omitempty"`
+	Redoes  int64      `json:",omitempty"`
+}
+
+// Change is the old and new value of one field of an item, formatted for
+// display. Empty means the field was unset.
+type Change struct {
+	Field string
+	Old   string `json:",omitempty"`
+	New   string `json:",omitempty"`
+}
+
+type historyKey int
+
+const (
+	traceIDKey historyKey = iota
+	actorKey
+	undoesKey
+	redoesKey
+)
+
+// WithTraceID returns a context whose writes are recorded with traceID.
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey, traceID)
+}
+
+func TraceID(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey).(string)
+	return traceID
+}
+
+// WithActor returns a context whose writes are recorded as made by actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+func Actor(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey).(string)
+	return actor
+}
+
+// WithUndo returns a context whose writes are recorded as undoing the
+// operation starting at seq.
+func WithUndo(ctx context.Context, seq int64) context.Context {
+	return context.WithValue(ctx, undoesKey, seq)
+}
+
+// WithRedo returns a context whose writes are recorded as redoing the
+// operation starting at seq.
+func WithRedo(ctx context.Context, seq int64) context.Context {
+	return context.WithValue(ctx, redoesKey, seq)
+}
+
+// unaudited lists the fields left out of Changes: the ID is the event's
+// ItemID, and UpdatedAt changes with every write and only repeats its Time.
+var unaudited = map[string]bool{"ID": true, "UpdatedAt": true}
+
+// historyEvents describes how prev became next, one event per item that was
+// added, changed or removed. Items that only moved position are left out.
+// Seq is left for the store to fill in.
+func historyEvents(ctx context.Context, prev, next []todo.Item) []Event {
+	base := Event{Time: todo.Now(), TraceID: TraceID(ctx), Actor: Actor(ctx)}
+	base.Undoes, _ = ctx.Value(undoesKey).(int64)
+	base.Redoes, _ = ctx.Value(redoesKey).(int64)
+
+	before := make(map[string]todo.Item, len(prev))
+	for _, item := range prev {
+		before[item.ID] = item
+	}
+
+	var events []Event
+	for _, item := range next {
+		event := base
+		event.ItemID = item.ID
+		event.After = ptrTo(item.Clone())
+
+		old, ok := before[item.ID]
+		delete(before, item.ID)
+		if ok {
+			event.Op = JournalUpdate
+			event.Before = ptrTo(old.Clone())
+			event.Changes = itemChanges(old, item)
+			if len(event.Changes) == 0 {
+				continue
+			}
+		} else {
+			event.Op = JournalAdd
+			event.Changes = itemChanges(todo.Item{}, item)
+		}
+		events = append(events, event)
+	}
+
+	for _, item := range prev {
+		if _, ok := before[item.ID]; !ok {
+			continue
+		}
+		event := base
+		event.ItemID = item.ID
+		event.Op = JournalRemove
+		event.Before = ptrTo(item.Clone())
+		events = append(events, event)
+	}
+
+	return events
+}
+
+// itemChanges lists the fields that differ between before and after in the
+// order they are declared on todo.Item.
+func itemChanges(before, after todo.Item) []Change {
+	b, a := reflect.ValueOf(before), reflect.ValueOf(after)
+
+	var changes []Change
+	for i := range b.NumField() {
+		name := b.Type().Field(i).Name
+		if unaudited[name] || reflect.DeepEqual(b.Field(i).Interface(), a.Field(i).Interface()) {
+			continue
+		}
+		changes = append(changes, Change{
+			Field: name,
+			Old:   formatField(b.Field(i).Interface()),
+			New:   formatField(a.Field(i).Interface()),
+		})
+	}
+	return changes
+}
+
+func formatField(value any) string {
+	switch v := value.(type) {
+	case []string:
+		return strings.Join(v, ",")
+	case todo.DueDate:
+		if v.IsZero() {
+			return ""
+		}
+		return v.String()
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
+
+func byItem(itemID string) func(Event) bool {
+	return func(event Event) bool { return event.ItemID == itemID }
+}
+
+func byActor(actor string) func(Event) bool {
+	return func(event Event) bool { return event.Actor == actor }
+}
+
+func filterEvents(events []Event, match func(Event) bool) []Event {
+	var result []Event
+	for _, event := range events {
+		if match(event) {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+// historyLog appends events as JSON lines next to the todo file. It is only
+// used from the FileStore actor. size is the length of the file as last read
+// or written, so that events appended by another process are noticed.
+type historyLog struct {
+	path string
+	seq  int64
+	size int64
+	read bool
+}
+
+// append writes events to the end of the history and returns the size the
+// file had before them, for discard. The last Seq is read again whenever the
+// file changed since this process last saw it.
+func (h *historyLog) append(ctx context.Context, events []Event) (int64, error) {
+	var size int64
+	if info, err := os.Stat(h.path); err == nil {
+		size = info.Size()
+	} else if !os.IsNotExist(err) {
+		return 0, err
+	}
+	if !h.read || size != h.size {
+		if _, err := h.readAll(ctx); err != nil {
+			return 0, err
+		}
+		size = h.size
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range events {
+		events[i].Seq = h.seq + int64(i) + 1
+		if err := enc.Encode(events[i]); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := appendSynced(h.path, buf.Bytes()); err != nil {
+		return 0, err
+	}
+	h.seq += int64(len(events))
+	h.size = size + int64(buf.Len())
+	return size, nil
+}
+
+// discard cuts the history back to size, removing the count events last
+// appended for a write that then failed.
+func (h *historyLog) discard(size int64, count int) error {
+	if err := os.Truncate(h.path, size); err != nil {
+		return err
+	}
+	h.seq -= int64(count)
+	h.size = size
+	return nil
+}
+
+// readAll returns every recorded event. Like the journal, a damaged last
+// line left by a crash mid-append is cut off.
+func (h *historyLog) readAll(ctx context.Context) ([]Event, error) {
+	file, err := os.Open(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			h.seq, h.size, h.read = 0, 0, true
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var events []Event
+	var valid int64
+	reader := bufio.NewReader(file)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if len(data) == 0 && err == io.EOF {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		var event Event
+		if jsonErr := json.Unmarshal(data, &event); jsonErr != nil || data[len(data)-1] != '\n' {
+			if _, peekErr := reader.Peek(1); peekErr != io.EOF {
+				return nil, fmt.Errorf("history line %d: %w", line, jsonErr)
+			}
+			slog.WarnContext(ctx, "Discarding incomplete last history entry", "line", line)
+			if err := os.Truncate(h.path, valid); err != nil {
+				return nil, err
+			}
+			break
+		}
+
+		events = append(events, event)
+		valid += int64(len(data))
+	}
+
+	h.seq = 0
+	if len(events) > 0 {
+		h.seq = events[len(events)-1].Seq
+	}
+	h.size, h.read = valid, true
+	return events, nil
+}
@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"testing"
+
+	"todo-app/todo"
+)
+
+func TestHistoryRecordsEveryChange(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, store Store) {
+		ctx := WithActor(WithTraceID(context.Background(), "trace-1"), "alice")
+
+		if err := store.SaveTodos(ctx, []todo.Item{
+			{ID: "1", Description: "write report", Status: todo.NotStarted},
+			{ID: "2", Description: "file taxes", Status: todo.NotStarted},
+		}); err != nil {
+			t.Fatalf("SaveTodos failed: %v", err)
+		}
+
+		err := store.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+			todos[0].Status = todo.Completed
+			todos[0].Description = "write the report"
+			return todos[:1], nil
+		})
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+
+		// Reordering alone is not a change.
+		err = store.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+			return todos, nil
+		})
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+
+		events, err := store.History(ctx, "1")
+		if err != nil {
+			t.Fatalf("History failed: %v", err)
+		}
+		if len(events) != 2 {
+			t.Fatalf("expected 2 events, got %+v", events)
+		}
+
+		added, updated := events[0], events[1]
+		if added.Op != JournalAdd || added.Before != nil || added.After == nil || added.After.Description != "write report" {
+			t.Errorf("unexpected add event: %+v", added)
+		}
+		if updated.Op != JournalUpdate || added.Seq >= updated.Seq {
+			t.Errorf("unexpected update event: %+v", updated)
+		}
+		if updated.TraceID != "trace-1" || updated.Actor != "alice" {
+			t.Errorf("expected trace-1 by alice, got %q by %q", updated.TraceID, updated.Actor)
+		}
+
+		want := []Change{
+			{Field: "Description", Old: "write report", New: "write the report"},
+			{Field: "Status", Old: todo.NotStarted, New: todo.Completed},
+		}
+		if !slices.Equal(updated.Changes, want) {
+			t.Errorf("expected changes %+v, got %+v", want, updated.Changes)
+		}
+
+		events, err = store.History(ctx, "2")
+		if err != nil {
+			t.Fatalf("History failed: %v", err)
+		}
+		if len(events) != 2 || events[1].Op != JournalRemove || events[1].Before == nil || events[1].After != nil {
+			t.Errorf("expected an add and a remove event, got %+v", events)
+		}
+
+		events, err = store.HistoryByActor(ctx, "alice")
+		if err != nil {
+			t.Fatalf("HistoryByActor failed: %v", err)
+		}
+		if len(events) != 4 {
+			t.Errorf("expected 4 events by alice, got %+v", events)
+		}
+		events, err = store.HistoryByActor(ctx, "bob")
+		if err != nil || len(events) != 0 {
+			t.Errorf("expected no events by bob, got %+v, %v", events, err)
+		}
+	})
+}
+
+func TestHistorySurvivesReopen(t *testing.T) {
+	path := t.TempDir() + "/todos.json"
+	ctx := context.Background()
+
+	fs := NewFileStore(path)
+	if err := fs.SaveTodos(ctx, []todo.Item{{ID: "1", Description: "task", Status: todo.NotStarted}}); err != nil {
+		t.Fatalf("SaveTodos failed: %v", err)
+	}
+	fs.Close()
+
+	fs = NewFileStore(path)
+	defer fs.Close()
+	err := fs.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+		todos[0].Status = todo.Started
+		return todos, nil
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	events, err := fs.History(ctx, "1")
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(events) != 2 || events[0].Seq != 1 || events[1].Seq != 2 {
+		t.Errorf("expected events 1 and 2, got %+v", events)
+	}
+}
+
+func TestHistoryFollowsFailedWrites(t *testing.T) {
+	path := t.TempDir() + "/todos.json"
+	ctx := context.Background()
+	fs := NewFileStore(path)
+	defer fs.Close()
+
+	if err := fs.SaveTodos(ctx, []todo.Item{{ID: "1", Description: "task", Status: todo.NotStarted}}); err != nil {
+		t.Fatalf("SaveTodos failed: %v", err)
+	}
+
+	// A change that is not written leaves no events behind.
+	errDiskFull := errors.New("disk full")
+	fs.encode = func(w io.Writer, todos []todo.Item) error {
+		return errDiskFull
+	}
+	err := fs.SaveTodos(ctx, []todo.Item{{ID: "2", Description: "other", Status: todo.NotStarted}})
+	if !errors.Is(err, errDiskFull) {
+		t.Fatalf("expected injected error, got %v", err)
+	}
+	fs.encode = encodeJSON
+	for id, want := range map[string]int{"1": 1, "2": 0} {
+		if events, err := fs.History(ctx, id); err != nil || len(events) != want {
+			t.Errorf("expected %d event(s) for %s, got %+v (%v)", want, id, events, err)
+		}
+	}
+
+	// A change whose events cannot be recorded is not written.
+	if err := os.Remove(path + ".history"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if err := os.Mkdir(path+".history", 0755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	err = fs.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+		todos[0].Status = todo.Completed
+		return todos, nil
+	})
+	if err == nil {
+		t.Fatal("expected Update to fail without a history")
+	}
+	todos, err := fs.LoadTodos(ctx)
+	if err != nil {
+		t.Fatalf("LoadTodos failed: %v", err)
+	}
+	if todos[0].Status != todo.NotStarted {
+		t.Errorf("expected the change to be refused, got %q", todos[0].Status)
+	}
+}
+
+func TestHistorySeqFollowsOtherWriters(t *testing.T) {
+	path := t.TempDir() + "/todos.json"
+	ctx := context.Background()
+
+	// Two stores on one file stand in for two processes.
+	first, second := NewFileStore(path), NewFileStore(path)
+	defer first.Close()
+	defer second.Close()
+
+	for i, store := range []*FileStore{first, second, first} {
+		err := store.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+			return append(todos, todo.Item{ID: string(rune('a' + i)), Description: "task", Status: todo.NotStarted}), nil
+		})
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+	}
+
+	events, err := first.history.readAll(ctx)
+	if err != nil {
+		t.Fatalf("readAll failed: %v", err)
+	}
+	var seqs []int64
+	for _, event := range events {
+		seqs = append(seqs, event.Seq)
+	}
+	if !slices.Equal(seqs, []int64{1, 2, 3}) {
+		t.Errorf("expected Seq 1, 2 and 3, got %v", seqs)
+	}
+}
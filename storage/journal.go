@@ -0,0 +1,315 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"slices"
+	"time"
+
+	"todo-app/todo"
+)
+
+const (
+	JournalAdd    = "add"
+	JournalUpdate = "update"
+	JournalRemove = "remove"
+)
+
+// DefaultCompactEvery is the number of journal entries written between
+// snapshots when NewJournalStore is given a non-positive interval.
+const DefaultCompactEvery = 1000
+
+// JournalEntry is one line of the journal. Add and update entries carry the
+// full item and the position it ends up at; remove entries carry the item as
+// it was before removal. Commit marks the last entry of a save: a save is
+// only replayed once its commit is on disk.
+type JournalEntry struct {
+	Seq      int64
+	Time     time.Time
+	Op       string
+	Position int
+	Item     todo.Item
+	Commit   bool `json:",omitempty"`
+}
+
+type journalSnapshot struct {
+	Seq   int64
+	Todos []todo.Item
+}
+
+// journal stores the list as a snapshot plus an append-only file of changes
+// made since the snapshot. It is only used from the FileStore actor.
+type journal struct {
+	path         string
+	snapshotPath string
+	compactEvery int
+	seq          int64
+	pending      int
+}
+
+// NewJournalStore returns a FileStore that appends each change to the
+// journal at path instead of rewriting the whole list. Every compactEvery
+// entries the list is written to path+".snapshot" and the journal is reset.
+func NewJournalStore(path string, compactEvery int) *FileStore {
+	if compactEvery <= 0 {
+		compactEvery = DefaultCompactEvery
+	}
+	fs := newFileStore(path)
+	fs.journal = &journal{
+		path:         path,
+		snapshotPath: path + ".snapshot",
+		compactEvery: compactEvery,
+	}
+	go fs.actor()
+	return fs
+}
+
+func (j *journal) load(ctx context.Context) ([]todo.Item, error) {
+	snapshot, err := j.readSnapshot()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read journal snapshot", "error", err)
+		return nil, err
+	}
+
+	entries, err := j.readEntries(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read journal", "error", err)
+		return nil, err
+	}
+
+	todos := snapshot.Todos
+	j.seq = snapshot.Seq
+	j.pending = 0
+	for _, entry := range entries {
+		// Entries already folded into the snapshot are left behind when a
+		// crash happens between writing the snapshot and resetting the journal.
+		if entry.Seq <= snapshot.Seq {
+			continue
+		}
+		todos = applyEntry(todos, entry)
+		j.seq = entry.Seq
+		j.pending++
+	}
+
+	slog.InfoContext(ctx, "Loaded todos from journal", "count", len(todos), "replayed", j.pending)
+	return todos, nil
+}
+
+func (j *journal) save(ctx context.Context, prev, todos []todo.Item) error {
+	entries := diffTodos(prev, todos)
+	if len(entries) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	entries[len(entries)-1].Commit = true
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range entries {
+		entries[i].Seq = j.seq + int64(i) + 1
+		entries[i].Time = now
+		if err := enc.Encode(entries[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := appendSynced(j.path, buf.Bytes()); err != nil {
+		slog.ErrorContext(ctx, "Failed to append to journal", "error", err)
+		return err
+	}
+	j.seq += int64(len(entries))
+	j.pending += len(entries)
+
+	slog.InfoContext(ctx, "Appended to journal", "entries", len(entries), "count", len(todos))
+
+	// The entries are already durable, so a failed compaction does not fail
+	// the save; the next one tries again.
+	if j.pending >= j.compactEvery {
+		if err := j.compact(ctx, todos); err != nil {
+			slog.ErrorContext(ctx, "Failed to compact journal", "error", err)
+		}
+	}
+	return nil
+}
+
+// compact writes the current list as a snapshot and then resets the journal.
+// The snapshot records the last sequence number it contains, so replaying a
+// journal that was not reset yet is harmless.
+func (j *journal) compact(ctx context.Context, todos []todo.Item) error {
+	snapshot := journalSnapshot{Seq: j.seq, Todos: todos}
+	err := writeFileAtomic(ctx, j.snapshotPath, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(snapshot)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.Truncate(j.path, 0); err != nil {
+		slog.ErrorContext(ctx, "Failed to reset journal", "error", err)
+		return err
+	}
+	j.pending = 0
+
+	slog.InfoContext(ctx, "Compacted journal into snapshot", "seq", j.seq, "count", len(todos))
+	return nil
+}
+
+func (j *journal) readSnapshot() (journalSnapshot, error) {
+	snapshot := journalSnapshot{Todos: []todo.Item{}}
+
+	data, err := os.ReadFile(j.snapshotPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return snapshot, nil
+		}
+		return snapshot, err
+	}
+
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return snapshot, err
+	}
+	if snapshot.Todos == nil {
+		snapshot.Todos = []todo.Item{}
+	}
+	return snapshot, nil
+}
+
+// readEntries returns the committed entries in the journal. A crash
+// mid-append can leave a damaged last line or a save without its commit;
+// either is cut off, so that a save is replayed whole or not at all and later
+// appends start on a clean line.
+func (j *journal) readEntries(ctx context.Context) ([]JournalEntry, error) {
+	file, err := os.Open(j.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries, uncommitted []JournalEntry
+	var size, committed int64
+	reader := bufio.NewReader(file)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if len(data) == 0 && err == io.EOF {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		var entry JournalEntry
+		if jsonErr := json.Unmarshal(data, &entry); jsonErr != nil || data[len(data)-1] != '\n' {
+			if _, peekErr := reader.Peek(1); peekErr != io.EOF {
+				return nil, fmt.Errorf("journal line %d: %w", line, jsonErr)
+			}
+			slog.WarnContext(ctx, "Discarding incomplete last journal entry", "line", line)
+			break
+		}
+
+		uncommitted = append(uncommitted, entry)
+		size += int64(len(data))
+		if entry.Commit {
+			entries = append(entries, uncommitted...)
+			uncommitted = nil
+			committed = size
+		}
+	}
+
+	if len(uncommitted) > 0 {
+		slog.WarnContext(ctx, "Discarding uncommitted journal entries", "count", len(uncommitted))
+	}
+	if info, err := file.Stat(); err == nil && info.Size() == committed {
+		return entries, nil
+	}
+	return entries, os.Truncate(j.path, committed)
+}
+
+// diffTodos returns the entries that turn prev into next when replayed in
+// order. Removals come first, then each position of next is checked in turn
+// so that appends and in-place edits produce a single entry each.
+func diffTodos(prev, next []todo.Item) []JournalEntry {
+	var entries []JournalEntry
+
+	keep := make(map[string]bool, len(next))
+	for _, item := range next {
+		keep[item.ID] = true
+	}
+
+	current := make([]todo.Item, 0, len(prev))
+	for _, item := range prev {
+		if !keep[item.ID] {
+			entries = append(entries, JournalEntry{Op: JournalRemove, Item: item})
+			continue
+		}
+		current = append(current, item)
+	}
+
+	for i, item := range next {
+		if i < len(current) && current[i].ID == item.ID {
+			if !reflect.DeepEqual(current[i], item) {
+				current[i] = item
+				entries = append(entries, JournalEntry{Op: JournalUpdate, Position: i, Item: item})
+			}
+			continue
+		}
+
+		op := JournalAdd
+		if j := indexOfItem(current, item.ID); j >= 0 {
+			op = JournalUpdate
+			current = slices.Delete(current, j, j+1)
+		}
+		current = slices.Insert(current, min(i, len(current)), item)
+		entries = append(entries, JournalEntry{Op: op, Position: i, Item: item})
+	}
+
+	return entries
+}
+
+func applyEntry(todos []todo.Item, entry JournalEntry) []todo.Item {
+	if i := indexOfItem(todos, entry.Item.ID); i >= 0 {
+		todos = slices.Delete(todos, i, i+1)
+	}
+
+	switch entry.Op {
+	case JournalAdd, JournalUpdate:
+		pos := min(max(entry.Position, 0), len(todos))
+		todos = slices.Insert(todos, pos, entry.Item)
+	}
+
+	return todos
+}
+
+func indexOfItem(todos []todo.Item, id string) int {
+	return slices.IndexFunc(todos, func(item todo.Item) bool {
+		return item.ID == id
+	})
+}
+
+func appendSynced(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
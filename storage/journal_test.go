@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"todo-app/todo"
+)
+
+func TestJournalReplaysChangesAfterReopen(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir() + "/todos.journal"
+	fs := NewJournalStore(path, 100)
+
+	for _, desc := range []string{"test1", "test2", "test3"} {
+		err := fs.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+			return todo.AddNewItem(todos, desc)
+		})
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+	}
+	err := fs.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+		todos, err := todo.RemoveItem(todos, "test1")
+		if err != nil {
+			return nil, err
+		}
+		return todos, todo.UpdateStatus(todos, "test3", todo.Completed)
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	fs.Close()
+
+	entries, err := (&journal{path: path}).readEntries(ctx)
+	if err != nil {
+		t.Fatalf("readEntries failed: %v", err)
+	}
+	if len(entries) != 5 {
+		t.Errorf("expected 5 journal entries, got %d", len(entries))
+	}
+
+	fs = NewJournalStore(path, 100)
+	defer fs.Close()
+
+	loaded, err := fs.LoadTodos(ctx)
+	if err != nil {
+		t.Fatalf("LoadTodos failed: %v", err)
+	}
+	if len(loaded) != 2 ||
+		loaded[0].Description != "test2" ||
+		loaded[1].Description != "test3" ||
+		loaded[1].Status != todo.Completed {
+		t.Errorf("Loaded todos do not match: %+v", loaded)
+	}
+}
+
+func TestJournalCompaction(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir() + "/todos.journal"
+	fs := NewJournalStore(path, 3)
+	defer fs.Close()
+
+	for _, desc := range []string{"test1", "test2", "test3", "test4"} {
+		err := fs.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+			return todo.AddNewItem(todos, desc)
+		})
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+	}
+
+	if _, err := os.Stat(path + ".snapshot"); err != nil {
+		t.Fatalf("expected snapshot to be written: %v", err)
+	}
+	entries, err := (&journal{path: path}).readEntries(ctx)
+	if err != nil {
+		t.Fatalf("readEntries failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected 1 journal entry after compaction, got %d", len(entries))
+	}
+
+	loaded, err := fs.LoadTodos(ctx)
+	if err != nil {
+		t.Fatalf("LoadTodos failed: %v", err)
+	}
+	if len(loaded) != 4 {
+		t.Errorf("expected 4 todos, got %d", len(loaded))
+	}
+}
+
+func TestJournalSurvivesFailedCompaction(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	fs := NewJournalStore(dir+"/todos.journal", 2)
+	defer fs.Close()
+	fs.journal.snapshotPath = dir + "/missing/todos.journal.snapshot"
+
+	for _, desc := range []string{"test1", "test2", "test3"} {
+		err := fs.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+			return todo.AddNewItem(todos, desc)
+		})
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+	}
+
+	entries, err := fs.journal.readEntries(ctx)
+	if err != nil {
+		t.Fatalf("readEntries failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("expected 3 journal entries without compaction, got %d", len(entries))
+	}
+	if events, err := fs.History(ctx, entries[2].Item.ID); err != nil || len(events) != 1 {
+		t.Errorf("expected the last addition in the history, got %+v (%v)", events, err)
+	}
+}
+
+func TestJournalIgnoresIncompleteLastEntry(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir() + "/todos.journal"
+	fs := NewJournalStore(path, 100)
+
+	err := fs.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+		return todo.AddNewItem(todos, "test1")
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	fs.Close()
+
+	if err := appendSynced(path, []byte(`{"Seq":2,"Op":"add","It`)); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	fs = NewJournalStore(path, 100)
+	defer fs.Close()
+
+	loaded, err := fs.LoadTodos(ctx)
+	if err != nil {
+		t.Fatalf("LoadTodos failed: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].Description != "test1" {
+		t.Errorf("Loaded todos do not match: %+v", loaded)
+	}
+
+	err = fs.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+		return todo.AddNewItem(todos, "test2")
+	})
+	if err != nil {
+		t.Fatalf("Update after damaged entry failed: %v", err)
+	}
+
+	loaded, err = fs.LoadTodos(ctx)
+	if err != nil {
+		t.Fatalf("LoadTodos failed: %v", err)
+	}
+	if len(loaded) != 2 {
+		t.Errorf("expected 2 todos, got %d", len(loaded))
+	}
+}
+
+func TestJournalDropsUncommittedSave(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir() + "/todos.journal"
+	fs := NewJournalStore(path, 100)
+
+	err := fs.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+		return todo.AddNewItem(todos, "test1")
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	fs.Close()
+
+	// The first entry of a two-item save, cut off before its commit.
+	partial := `{"Seq":2,"Op":"add","Position":1,"Item":{"ID":"2","Description":"test2","Status":"not started"}}` + "\n"
+	if err := appendSynced(path, []byte(partial)); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	fs = NewJournalStore(path, 100)
+	defer fs.Close()
+
+	loaded, err := fs.LoadTodos(ctx)
+	if err != nil {
+		t.Fatalf("LoadTodos failed: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].Description != "test1" {
+		t.Errorf("expected the uncommitted save to be dropped, got %+v", loaded)
+	}
+
+	err = fs.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+		return todo.AddNewItem(todos, "test3")
+	})
+	if err != nil {
+		t.Fatalf("Update after uncommitted save failed: %v", err)
+	}
+	entries, err := fs.journal.readEntries(ctx)
+	if err != nil {
+		t.Fatalf("readEntries failed: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Seq != 2 || entries[1].Item.Description != "test3" {
+		t.Errorf("expected the dropped entry to be replaced, got %+v", entries)
+	}
+}
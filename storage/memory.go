@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"sync"
+
+	"todo-app/todo"
+)
+
+// MemoryStore keeps todos in memory only. It is meant for tests and for
+// servers that do not need to persist anything.
+type MemoryStore struct {
+	mu     sync.Mutex
+	todos  []todo.Item
+	events []Event
+	index  *searchIndex
+	closed bool
+}
+
+func NewMemoryStore(todos ...todo.Item) *MemoryStore {
+	todos = cloneTodos(todos)
+	return &MemoryStore{todos: todos, index: newSearchIndex(todos)}
+}
+
+func (ms *MemoryStore) LoadTodos(ctx context.Context) ([]todo.Item, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if err := ms.usable(ctx); err != nil {
+		return nil, err
+	}
+	return cloneTodos(ms.todos), nil
+}
+
+func (ms *MemoryStore) SaveTodos(ctx context.Context, todos []todo.Item) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if err := ms.usable(ctx); err != nil {
+		return err
+	}
+	ms.replace(ctx, todos)
+	return nil
+}
+
+func (ms *MemoryStore) Update(ctx context.Context, fn UpdateFunc) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if err := ms.usable(ctx); err != nil {
+		return err
+	}
+
+	todos, err := fn(cloneTodos(ms.todos))
+	if err != nil {
+		return err
+	}
+
+	ms.replace(ctx, todos)
+	return nil
+}
+
+func (ms *MemoryStore) History(ctx context.Context, itemID string) ([]Event, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if err := ms.usable(ctx); err != nil {
+		return nil, err
+	}
+	return filterEvents(ms.events, byItem(itemID)), nil
+}
+
+func (ms *MemoryStore) HistoryByActor(ctx context.Context, actor string) ([]Event, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if err := ms.usable(ctx); err != nil {
+		return nil, err
+	}
+	return filterEvents(ms.events, byActor(actor)), nil
+}
+
+func (ms *MemoryStore) Search(ctx context.Context, query string) ([]Match, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if err := ms.usable(ctx); err != nil {
+		return nil, err
+	}
+	return ms.index.search(query, cloneTodos(ms.todos)), nil
+}
+
+// replace stores todos and records how they differ from the previous list.
+func (ms *MemoryStore) replace(ctx context.Context, todos []todo.Item) {
+	todos = cloneTodos(todos)
+	todo.EnsureIDs(todos)
+	for _, event := range historyEvents(ctx, ms.todos, todos) {
+		event.Seq = int64(len(ms.events)) + 1
+		ms.events = append(ms.events, event)
+	}
+	ms.index.update(ms.todos, todos)
+	ms.todos = todos
+}
+
+func (ms *MemoryStore) Close() error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.closed = true
+	return nil
+}
+
+func (ms *MemoryStore) usable(ctx context.Context) error {
+	if ms.closed {
+		return ErrStoreClosed
+	}
+	return ctx.Err()
+}
+
+// cloneTodos deep-copies todos so that callers and stores never share
+// memory.
+func cloneTodos(todos []todo.Item) []todo.Item {
+	cloned := make([]todo.Item, len(todos))
+	for i, item := range todos {
+		cloned[i] = item.Clone()
+	}
+	return cloned
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"todo-app/todo"
+)
+
+// Match is an item found by Search and how well it matches, higher being
+// better.
+type Match struct {
+	Item  todo.Item
+	Score float64
+}
+
+// prefixWeight is how much a term that only starts with a query word counts
+// compared to one equal to it.
+const prefixWeight = 0.5
+
+// Tokenize splits s into lower-case words of letters and digits, which is
+// how both items and queries are indexed.
+func Tokenize(s string) []string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return words
+}
+
+// searchIndex maps the words of item descriptions, notes and tags to the
+// items they appear in.
+type searchIndex struct {
+	postings map[string]map[string]int // term, item ID, occurrences
+	docs     map[string][]string       // item ID, its terms
+	terms    []string                  // sorted, nil when stale
+}
+
+func newSearchIndex(todos []todo.Item) *searchIndex {
+	idx := &searchIndex{
+		postings: make(map[string]map[string]int),
+		docs:     make(map[string][]string),
+	}
+	for _, item := range todos {
+		idx.add(item)
+	}
+	return idx
+}
+
+func indexedTerms(item todo.Item) []string {
+	terms := Tokenize(item.Description)
+	terms = append(terms, Tokenize(item.Notes)...)
+	for _, tag := range item.Tags {
+		terms = append(terms, Tokenize(tag)...)
+	}
+	return terms
+}
+
+func (idx *searchIndex) add(item todo.Item) {
+	terms := indexedTerms(item)
+	idx.docs[item.ID] = terms
+	for _, term := range terms {
+		if idx.postings[term] == nil {
+			idx.postings[term] = make(map[string]int)
+			idx.terms = nil
+		}
+		idx.postings[term][item.ID]++
+	}
+}
+
+func (idx *searchIndex) remove(id string) {
+	for _, term := range idx.docs[id] {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+			idx.terms = nil
+		}
+	}
+	delete(idx.docs, id)
+}
+
+// update reindexes the items whose words differ between prev and next and
+// drops those that are gone.
+func (idx *searchIndex) update(prev, next []todo.Item) {
+	before := make(map[string]todo.Item, len(prev))
+	for _, item := range prev {
+		before[item.ID] = item
+	}
+	for _, item := range next {
+		old, ok := before[item.ID]
+		delete(before, item.ID)
+		if ok && old.Description == item.Description && old.Notes == item.Notes && slices.Equal(old.Tags, item.Tags) {
+			continue
+		}
+		idx.remove(item.ID)
+		idx.add(item)
+	}
+	for id := range before {
+		idx.remove(id)
+	}
+}
+
+// matching returns the indexed terms that start with word, word itself
+// first when it is one of them.
+func (idx *searchIndex) matching(word string) []string {
+	if idx.terms == nil {
+		idx.terms = make([]string, 0, len(idx.postings))
+		for term := range idx.postings {
+			idx.terms = append(idx.terms, term)
+		}
+		slices.Sort(idx.terms)
+	}
+
+	i, _ := slices.BinarySearch(idx.terms, word)
+	var terms []string
+	for ; i < len(idx.terms) && strings.HasPrefix(idx.terms[i], word); i++ {
+		terms = append(terms, idx.terms[i])
+	}
+	return terms
+}
+
+// search returns the items of todos that have a term starting with every
+// word of query, best match first. Rare words count for more than common
+// ones, whole words for more than prefixes, and matches in short
+// descriptions for more than in long ones.
+func (idx *searchIndex) search(query string, todos []todo.Item) []Match {
+	words := Tokenize(query)
+	if len(words) == 0 {
+		return nil
+	}
+
+	var scores map[string]float64
+	for _, word := range words {
+		best := make(map[string]float64)
+		for _, term := range idx.matching(word) {
+			weight := 1.0
+			if term != word {
+				weight = prefixWeight
+			}
+			for id, count := range idx.postings[term] {
+				best[id] = max(best[id], weight*(1+math.Log(float64(count))))
+			}
+		}
+		idf := math.Log(1 + float64(len(idx.docs))/float64(max(len(best), 1)))
+		for id := range best {
+			best[id] *= idf
+		}
+
+		if scores == nil {
+			scores = best
+			continue
+		}
+		for id, score := range scores {
+			if extra, ok := best[id]; ok {
+				scores[id] = score + extra
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	var matches []Match
+	for _, item := range todos {
+		if score, ok := scores[item.ID]; ok {
+			length := float64(len(idx.docs[item.ID]))
+			matches = append(matches, Match{Item: item, Score: score / math.Sqrt(length)})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return matches
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+
+	"todo-app/todo"
+)
+
+func matchedIDs(matches []Match) []string {
+	var ids []string
+	for _, match := range matches {
+		ids = append(ids, match.Item.ID)
+	}
+	return ids
+}
+
+func TestSearch(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		if err := store.SaveTodos(ctx, []todo.Item{
+			{ID: "1", Description: "deploy staging", Status: todo.NotStarted},
+			{ID: "2", Description: "deploy prod after the release review", Status: todo.NotStarted},
+			{ID: "3", Description: "Deploy Prod", Status: todo.NotStarted, Tags: []string{"ops"}},
+			{ID: "4", Description: "write report", Status: todo.NotStarted, Tags: []string{"production"}},
+			{ID: "5", Description: "rotate keys", Notes: "steps are in the wiki", Status: todo.NotStarted},
+		}); err != nil {
+			t.Fatalf("SaveTodos failed: %v", err)
+		}
+
+		tests := []struct {
+			query string
+			want  []string
+		}{
+			{"deploy prod", []string{"3", "2"}},
+			{"DEPLOY", []string{"1", "3", "2"}},
+			{"prod", []string{"3", "2", "4"}},
+			{"ops", []string{"3"}},
+			{"wiki", []string{"5"}},
+			{"deploy report", nil},
+			{"", nil},
+		}
+
+		for _, tt := range tests {
+			matches, err := store.Search(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("Search(%q) failed: %v", tt.query, err)
+			}
+			if got := matchedIDs(matches); !slices.Equal(got, tt.want) {
+				t.Errorf("Search(%q): expected %v, got %v", tt.query, tt.want, got)
+			}
+		}
+
+		err := store.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+			todos[0].Description = "deploy prod hotfix"
+			todos[1].Notes = "runbook in the wiki"
+			return todos[:3], nil
+		})
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		matches, err := store.Search(ctx, "prod")
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if got := matchedIDs(matches); !slices.Equal(got, []string{"1", "3", "2"}) {
+			t.Errorf("expected the index to follow the update, got %v", got)
+		}
+		matches, err = store.Search(ctx, "wiki")
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if got := matchedIDs(matches); !slices.Equal(got, []string{"2"}) {
+			t.Errorf("expected the index to follow the notes, got %v", got)
+		}
+	})
+}
+
+func TestSearchIndexRebuiltOnLoad(t *testing.T) {
+	path := t.TempDir() + "/todos.json"
+	ctx := context.Background()
+
+	fs := NewFileStore(path)
+	defer fs.Close()
+	if err := fs.SaveTodos(ctx, []todo.Item{{ID: "1", Description: "deploy prod", Status: todo.NotStarted}}); err != nil {
+		t.Fatalf("SaveTodos failed: %v", err)
+	}
+	if _, err := fs.Search(ctx, "deploy"); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	edited := `[{"ID": "1", "Description": "rotate keys", "Status": "not started"}]`
+	if err := os.WriteFile(path, []byte(edited), 0644); err != nil {
+		t.Fatalf("failed to edit the file: %v", err)
+	}
+
+	matches, err := fs.Search(ctx, "deploy")
+	if err != nil || len(matches) != 0 {
+		t.Errorf("expected the old words to be gone, got %+v, %v", matches, err)
+	}
+	matches, err = fs.Search(ctx, "rot")
+	if err != nil || len(matches) != 1 {
+		t.Errorf("expected the edited item to be found, got %+v, %v", matches, err)
+	}
+}
@@ -0,0 +1,276 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"sync"
+
+	"todo-app/todo"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS todos (
+	id       TEXT PRIMARY KEY,
+	position INTEGER NOT NULL,
+	data     TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS history (
+	seq     INTEGER PRIMARY KEY AUTOINCREMENT,
+	item_id TEXT NOT NULL,
+	data    TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS history_item_id ON history (item_id)`
+
+// SQLiteStore keeps todos in an SQLite database, one row per item. Updates
+// only write the rows that changed, so large lists are cheap to modify.
+type SQLiteStore struct {
+	Path string
+	db   *sql.DB
+
+	// mu is held for reading by every call that uses db and for writing by
+	// Close, so a call either runs to the end or sees the store closed.
+	mu     sync.RWMutex
+	closed bool
+}
+
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+
+	// A single connection serialises transactions the same way the file
+	// store's actor serialises requests.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{Path: path, db: db}, nil
+}
+
+func (ss *SQLiteStore) LoadTodos(ctx context.Context) ([]todo.Item, error) {
+	if err := ss.acquire(); err != nil {
+		return nil, err
+	}
+	defer ss.mu.RUnlock()
+
+	rows, err := ss.loadRows(ctx, ss.db)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load todos from database", "error", err)
+		return nil, err
+	}
+
+	todos := make([]todo.Item, len(rows))
+	for i, row := range rows {
+		todos[i] = row.item
+	}
+
+	slog.InfoContext(ctx, "Loaded todos from database", "count", len(todos))
+	return todos, nil
+}
+
+func (ss *SQLiteStore) SaveTodos(ctx context.Context, todos []todo.Item) error {
+	return ss.Update(ctx, func([]todo.Item) ([]todo.Item, error) {
+		return todos, nil
+	})
+}
+
+func (ss *SQLiteStore) Update(ctx context.Context, fn UpdateFunc) error {
+	if err := ss.acquire(); err != nil {
+		return err
+	}
+	defer ss.mu.RUnlock()
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := ss.loadRows(ctx, tx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load todos from database", "error", err)
+		return err
+	}
+
+	current := make([]todo.Item, len(rows))
+	existing := make(map[string]sqliteRow, len(rows))
+	for i, row := range rows {
+		current[i] = row.item
+		existing[row.item.ID] = row
+	}
+
+	todos, err := fn(cloneTodos(current))
+	if err != nil {
+		return err
+	}
+	todos = cloneTodos(todos)
+	todo.EnsureIDs(todos)
+
+	written := 0
+	kept := make(map[string]bool, len(todos))
+	for i, item := range todos {
+		kept[item.ID] = true
+
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		if row, ok := existing[item.ID]; ok && row.position == i && row.data == string(data) {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, `INSERT INTO todos (id, position, data) VALUES (?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET position = excluded.position, data = excluded.data`,
+			item.ID, i, string(data)); err != nil {
+			slog.ErrorContext(ctx, "Failed to write todo", "id", item.ID, "error", err)
+			return err
+		}
+		written++
+	}
+
+	for id := range existing {
+		if kept[id] {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM todos WHERE id = ?`, id); err != nil {
+			slog.ErrorContext(ctx, "Failed to delete todo", "id", id, "error", err)
+			return err
+		}
+		written++
+	}
+
+	for _, event := range historyEvents(ctx, current, todos) {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO history (item_id, data) VALUES (?, ?)`,
+			event.ItemID, string(data)); err != nil {
+			slog.ErrorContext(ctx, "Failed to record history", "id", event.ItemID, "error", err)
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Failed to commit todos", "error", err)
+		return err
+	}
+
+	slog.InfoContext(ctx, "Saved todos to database", "count", len(todos), "changed", written)
+	return nil
+}
+
+// History reads the events for the item from the history table, which
+// Update writes in the same transaction as the todos.
+func (ss *SQLiteStore) History(ctx context.Context, itemID string) ([]Event, error) {
+	return ss.queryHistory(ctx, `SELECT seq, data FROM history WHERE item_id = ? ORDER BY seq`, itemID)
+}
+
+func (ss *SQLiteStore) HistoryByActor(ctx context.Context, actor string) ([]Event, error) {
+	return ss.queryHistory(ctx, `SELECT seq, data FROM history WHERE coalesce(json_extract(data, '$.Actor'), '') = ? ORDER BY seq`, actor)
+}
+
+func (ss *SQLiteStore) queryHistory(ctx context.Context, query string, args ...any) ([]Event, error) {
+	if err := ss.acquire(); err != nil {
+		return nil, err
+	}
+	defer ss.mu.RUnlock()
+
+	rows, err := ss.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load history from database", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var seq int64
+		var data string
+		if err := rows.Scan(&seq, &data); err != nil {
+			return nil, err
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return nil, err
+		}
+		event.Seq = seq
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// Search builds an index of the current list for each query, as the
+// database keeps none of its own.
+func (ss *SQLiteStore) Search(ctx context.Context, query string) ([]Match, error) {
+	todos, err := ss.LoadTodos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newSearchIndex(todos).search(query, todos), nil
+}
+
+// acquire read-locks mu unless the store is closed; callers unlock it when
+// they are done with db.
+func (ss *SQLiteStore) acquire() error {
+	ss.mu.RLock()
+	if ss.closed {
+		ss.mu.RUnlock()
+		return ErrStoreClosed
+	}
+	return nil
+}
+
+// Close waits for running transactions and closes the database. It is safe
+// to call more than once.
+func (ss *SQLiteStore) Close() error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if ss.closed {
+		return nil
+	}
+	ss.closed = true
+	return ss.db.Close()
+}
+
+type sqliteRow struct {
+	item     todo.Item
+	position int
+	data     string
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func (ss *SQLiteStore) loadRows(ctx context.Context, q queryer) ([]sqliteRow, error) {
+	rows, err := q.QueryContext(ctx, `SELECT position, data FROM todos ORDER BY position`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []sqliteRow
+	for rows.Next() {
+		var row sqliteRow
+		if err := rows.Scan(&row.position, &row.data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(row.data), &row.item); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"todo-app/todo"
+)
+
+func TestSQLiteStorePersistsAcrossReopen(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir() + "/todos.db"
+
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+
+	err = store.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+		todos, err := todo.AddNewItem(todos, "test1")
+		if err != nil {
+			return nil, err
+		}
+		return todo.AddNewItem(todos, "test2")
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	err = store.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+		todos, err := todo.RemoveItem(todos, "test1")
+		if err != nil {
+			return nil, err
+		}
+		return todos, todo.UpdateStatus(todos, "test2", todo.Completed)
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	store.Close()
+
+	store, err = NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed on reopen: %v", err)
+	}
+	defer store.Close()
+
+	loaded, err := store.LoadTodos(ctx)
+	if err != nil {
+		t.Fatalf("LoadTodos failed: %v", err)
+	}
+	if len(loaded) != 1 ||
+		loaded[0].Description != "test2" ||
+		loaded[0].Status != todo.Completed {
+		t.Errorf("Loaded todos do not match: %+v", loaded)
+	}
+}
@@ -3,8 +3,11 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"sync"
 
 	"todo-app/todo"
 )
@@ -25,56 +28,371 @@ type saveRequest struct {
 	response chan error
 }
 
+type updateRequest struct {
+	ctx      context.Context
+	fn       UpdateFunc
+	response chan error
+}
+
+type historyRequest struct {
+	ctx      context.Context
+	match    func(Event) bool
+	response chan historyResponse
+}
+
+type historyResponse struct {
+	events []Event
+	err    error
+}
+
+type searchRequest struct {
+	ctx      context.Context
+	query    string
+	response chan searchResponse
+}
+
+type searchResponse struct {
+	matches []Match
+	err     error
+}
+
 type FileStore struct {
-	Path    string
-	loadCh  chan loadRequest
-	saveCh  chan saveRequest
-	closeCh chan struct{}
+	Path      string
+	loadCh    chan loadRequest
+	saveCh    chan saveRequest
+	updateCh  chan updateRequest
+	historyCh chan historyRequest
+	searchCh  chan searchRequest
+	closeCh   chan struct{}
+	doneCh    chan struct{}
+	closeOnce sync.Once
+	encode    func(w io.Writer, todos []todo.Item) error
+	journal   *journal
+	cache     *cachedTodos
+	history   *historyLog
+	index     *searchIndex
+}
+
+// cachedTodos is the list as last read or written by the actor, together
+// with the state of the backing files at that moment. The actor is the only
+// writer, so the cache stays valid until the files are changed externally.
+type cachedTodos struct {
+	todos []todo.Item
+	stamp storeStamp
+}
+
+type fileStamp struct {
+	modTime int64
+	size    int64
 }
 
+type storeStamp [2]fileStamp
+
 func NewFileStore(path string) *FileStore {
-	fs := &FileStore{
-		Path:    path,
-		loadCh:  make(chan loadRequest),
-		saveCh:  make(chan saveRequest),
-		closeCh: make(chan struct{}),
-	}
+	fs := newFileStore(path)
 	go fs.actor()
 	return fs
 }
 
+func newFileStore(path string) *FileStore {
+	return &FileStore{
+		Path:      path,
+		loadCh:    make(chan loadRequest),
+		saveCh:    make(chan saveRequest),
+		updateCh:  make(chan updateRequest),
+		historyCh: make(chan historyRequest),
+		searchCh:  make(chan searchRequest),
+		closeCh:   make(chan struct{}),
+		doneCh:    make(chan struct{}),
+		encode:    encodeJSON,
+		history:   &historyLog{path: path + ".history"},
+	}
+}
+
 func (fs *FileStore) actor() {
+	defer close(fs.doneCh)
+
 	for {
 		select {
 		case req := <-fs.loadCh:
-			todos, err := fs.loadFromDisk(req.ctx)
+			if err := req.ctx.Err(); err != nil {
+				req.response <- loadResponse{err: err}
+				continue
+			}
+			todos, err := fs.load(req.ctx)
 			req.response <- loadResponse{todos: todos, err: err}
 
 		case req := <-fs.saveCh:
-			err := fs.saveToDisk(req.ctx, req.todos)
+			if err := req.ctx.Err(); err != nil {
+				req.response <- err
+				continue
+			}
+			err := fs.save(req.ctx, req.todos)
 			req.response <- err
 
+		case req := <-fs.updateCh:
+			if err := req.ctx.Err(); err != nil {
+				req.response <- err
+				continue
+			}
+			req.response <- fs.updateOnDisk(req.ctx, req.fn)
+
+		case req := <-fs.historyCh:
+			if err := req.ctx.Err(); err != nil {
+				req.response <- historyResponse{err: err}
+				continue
+			}
+			events, err := fs.history.readAll(req.ctx)
+			req.response <- historyResponse{events: filterEvents(events, req.match), err: err}
+
+		case req := <-fs.searchCh:
+			if err := req.ctx.Err(); err != nil {
+				req.response <- searchResponse{err: err}
+				continue
+			}
+			todos, err := fs.load(req.ctx)
+			if err != nil {
+				req.response <- searchResponse{err: err}
+				continue
+			}
+			req.response <- searchResponse{matches: fs.index.search(req.query, todos)}
+
 		case <-fs.closeCh:
 			return
 		}
 	}
 }
 
+// LoadTodos returns the current list. It gives up with the context's error
+// if ctx is done first, and with ErrStoreClosed once Close has been called.
 func (fs *FileStore) LoadTodos(ctx context.Context) ([]todo.Item, error) {
 	respCh := make(chan loadResponse, 1)
-	fs.loadCh <- loadRequest{ctx: ctx, response: respCh}
-	resp := <-respCh
-	return resp.todos, resp.err
+	select {
+	case fs.loadCh <- loadRequest{ctx: ctx, response: respCh}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-fs.closeCh:
+		return nil, ErrStoreClosed
+	}
+
+	select {
+	case resp := <-respCh:
+		return resp.todos, resp.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 }
 
+func (fs *FileStore) LoadTodo(ctx context.Context, id string) (todo.Item, error) {
+	todos, err := fs.LoadTodos(ctx)
+	if err != nil {
+		return todo.Item{}, err
+	}
+	return todo.FindByID(todos, id)
+}
+
+// SaveTodos replaces the stored list. Like LoadTodos it returns early when
+// ctx is done or the store is closed; a save the actor has already started
+// still completes.
 func (fs *FileStore) SaveTodos(ctx context.Context, todos []todo.Item) error {
 	respCh := make(chan error, 1)
-	fs.saveCh <- saveRequest{ctx: ctx, todos: todos, response: respCh}
-	return <-respCh
+	select {
+	case fs.saveCh <- saveRequest{ctx: ctx, todos: todos, response: respCh}:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-fs.closeCh:
+		return ErrStoreClosed
+	}
+
+	return waitForResponse(ctx, respCh)
+}
+
+// Update runs fn against the current list and saves the result as a single
+// step of the actor, so no other load or save can interleave with it.
+func (fs *FileStore) Update(ctx context.Context, fn UpdateFunc) error {
+	respCh := make(chan error, 1)
+	select {
+	case fs.updateCh <- updateRequest{ctx: ctx, fn: fn, response: respCh}:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-fs.closeCh:
+		return ErrStoreClosed
+	}
+
+	return waitForResponse(ctx, respCh)
 }
 
-func (fs *FileStore) Close() {
-	close(fs.closeCh)
+// History returns the events recorded for the item with the given ID, read
+// from path+".history".
+func (fs *FileStore) History(ctx context.Context, itemID string) ([]Event, error) {
+	return fs.readHistory(ctx, byItem(itemID))
+}
+
+func (fs *FileStore) HistoryByActor(ctx context.Context, actor string) ([]Event, error) {
+	return fs.readHistory(ctx, byActor(actor))
+}
+
+func (fs *FileStore) readHistory(ctx context.Context, match func(Event) bool) ([]Event, error) {
+	respCh := make(chan historyResponse, 1)
+	select {
+	case fs.historyCh <- historyRequest{ctx: ctx, match: match, response: respCh}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-fs.closeCh:
+		return nil, ErrStoreClosed
+	}
+
+	select {
+	case resp := <-respCh:
+		return resp.events, resp.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Search returns the items with a word starting with every word of query,
+// best match first. The actor keeps an index of descriptions and tags that
+// it updates on every write and rebuilds whenever it reads the list from
+// disk.
+func (fs *FileStore) Search(ctx context.Context, query string) ([]Match, error) {
+	respCh := make(chan searchResponse, 1)
+	select {
+	case fs.searchCh <- searchRequest{ctx: ctx, query: query, response: respCh}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-fs.closeCh:
+		return nil, ErrStoreClosed
+	}
+
+	select {
+	case resp := <-respCh:
+		return resp.matches, resp.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close stops the actor after the request it is handling, if any, has been
+// written. It is safe to call more than once.
+func (fs *FileStore) Close() error {
+	fs.closeOnce.Do(func() {
+		close(fs.closeCh)
+	})
+	<-fs.doneCh
+	return nil
+}
+
+func waitForResponse(ctx context.Context, respCh chan error) error {
+	select {
+	case err := <-respCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (fs *FileStore) load(ctx context.Context) ([]todo.Item, error) {
+	if fs.cache != nil && fs.cache.stamp == fs.stamp() {
+		return cloneTodos(fs.cache.todos), nil
+	}
+
+	var todos []todo.Item
+	var err error
+	if fs.journal != nil {
+		todos, err = fs.journal.load(ctx)
+	} else {
+		todos, err = fs.loadFromDisk(ctx)
+	}
+	if err != nil {
+		fs.cache = nil
+		fs.index = nil
+		return nil, err
+	}
+
+	fs.remember(cloneTodos(todos))
+	fs.index = newSearchIndex(todos)
+	return todos, nil
+}
+
+func (fs *FileStore) save(ctx context.Context, todos []todo.Item) error {
+	if fs.journal != nil {
+		return fs.updateOnDisk(ctx, func([]todo.Item) ([]todo.Item, error) {
+			return todos, nil
+		})
+	}
+
+	prev, err := fs.load(ctx)
+	if err != nil {
+		return err
+	}
+	return fs.write(ctx, prev, todos)
+}
+
+// write persists todos and updates the cache. prev is the list todos was
+// derived from, which the journal needs to record only the difference and
+// the history to record what changed.
+func (fs *FileStore) write(ctx context.Context, prev, todos []todo.Item) error {
+	todos = cloneTodos(todos)
+	todo.EnsureIDs(todos)
+
+	// The events are appended first so that no change reaches the todos
+	// without its history, and cut off again if the todos cannot be written.
+	events := historyEvents(ctx, prev, todos)
+	var size int64
+	if len(events) > 0 {
+		var err error
+		if size, err = fs.history.append(ctx, events); err != nil {
+			slog.ErrorContext(ctx, "Failed to append to history", "error", err)
+			return err
+		}
+	}
+
+	var err error
+	if fs.journal != nil {
+		err = fs.journal.save(ctx, prev, todos)
+	} else {
+		err = fs.saveToDisk(ctx, todos)
+	}
+	if err != nil {
+		fs.cache = nil
+		fs.index = nil
+		if len(events) > 0 {
+			if err := fs.history.discard(size, len(events)); err != nil {
+				slog.ErrorContext(ctx, "Failed to remove the history of a failed write", "error", err)
+			}
+		}
+		return err
+	}
+
+	fs.remember(todos)
+	if fs.index == nil {
+		fs.index = newSearchIndex(todos)
+	} else {
+		fs.index.update(prev, todos)
+	}
+	return nil
+}
+
+func (fs *FileStore) remember(todos []todo.Item) {
+	fs.cache = &cachedTodos{todos: todos, stamp: fs.stamp()}
+}
+
+// stamp reports the modification time and size of the files backing the
+// store. Any difference from the cached stamp means the cache is stale.
+func (fs *FileStore) stamp() storeStamp {
+	stamp := storeStamp{statFile(fs.Path)}
+	if fs.journal != nil {
+		stamp[1] = statFile(fs.journal.snapshotPath)
+	}
+	return stamp
+}
+
+func statFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{modTime: -1, size: -1}
+	}
+	return fileStamp{modTime: info.ModTime().UnixNano(), size: info.Size()}
 }
 
 func (fs *FileStore) loadFromDisk(ctx context.Context) ([]todo.Item, error) {
@@ -95,23 +413,106 @@ func (fs *FileStore) loadFromDisk(ctx context.Context) ([]todo.Item, error) {
 		return nil, err
 	}
 
+	if todo.EnsureIDs(todos) {
+		slog.InfoContext(ctx, "Upgrading todo file with generated IDs")
+		if err := fs.saveToDisk(ctx, todos); err != nil {
+			return nil, err
+		}
+	}
+
 	slog.InfoContext(ctx, "Loaded todos from disk", "count", len(todos))
 	return todos, nil
 }
 
+func (fs *FileStore) updateOnDisk(ctx context.Context, fn UpdateFunc) error {
+	todos, err := fs.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	// load hands out a copy, so the cached list is still the one todos was
+	// read as and fn cannot have changed it.
+	prev := fs.cache.todos
+
+	todos, err = fn(todos)
+	if err != nil {
+		return err
+	}
+
+	return fs.write(ctx, prev, todos)
+}
+
 func (fs *FileStore) saveToDisk(ctx context.Context, todos []todo.Item) error {
-	file, err := os.Create(fs.Path)
+	err := writeFileAtomic(ctx, fs.Path, func(w io.Writer) error {
+		return fs.encode(w, todos)
+	})
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to create or open todo file", "error", err)
 		return err
 	}
-	defer file.Close()
 
-	if err := json.NewEncoder(file).Encode(todos); err != nil {
+	slog.InfoContext(ctx, "Saved todos to disk", "count", len(todos))
+	return nil
+}
+
+// writeFileAtomic writes to a temporary file in the same directory and
+// renames it over path once it has been synced, so a failed or interrupted
+// write never leaves a truncated file behind.
+func writeFileAtomic(ctx context.Context, path string, write func(w io.Writer) error) error {
+	dir := filepath.Dir(path)
+	file, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create temporary todo file", "error", err)
+		return err
+	}
+	tmpPath := file.Name()
+	defer os.Remove(tmpPath)
+
+	// CreateTemp uses 0600; keep the permissions os.Create used to give.
+	if err := file.Chmod(0644); err != nil {
+		file.Close()
+		slog.ErrorContext(ctx, "Failed to set todo file permissions", "error", err)
+		return err
+	}
+
+	if err := write(file); err != nil {
+		file.Close()
 		slog.ErrorContext(ctx, "Failed to encode todos", "error", err)
 		return err
 	}
 
-	slog.InfoContext(ctx, "Saved todos to disk", "count", len(todos))
+	if err := file.Sync(); err != nil {
+		file.Close()
+		slog.ErrorContext(ctx, "Failed to sync todo file", "error", err)
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		slog.ErrorContext(ctx, "Failed to close todo file", "error", err)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		slog.ErrorContext(ctx, "Failed to replace todo file", "error", err)
+		return err
+	}
+
+	if err := syncDir(dir); err != nil {
+		slog.WarnContext(ctx, "Failed to sync todo directory", "error", err)
+	}
+
 	return nil
 }
+
+func encodeJSON(w io.Writer, todos []todo.Item) error {
+	return json.NewEncoder(w).Encode(todos)
+}
+
+// syncDir flushes the directory entry so the rename itself survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"todo-app/todo"
+)
+
+func newBenchStore(b *testing.B, n int) *FileStore {
+	b.Helper()
+
+	todos := make([]todo.Item, n)
+	for i := range todos {
+		todos[i] = todo.Item{ID: todo.NewID(), Description: fmt.Sprintf("task %d", i), Status: todo.NotStarted}
+	}
+
+	fs := NewFileStore(b.TempDir() + "/todos.json")
+	if err := fs.SaveTodos(context.Background(), todos); err != nil {
+		b.Fatalf("setup failed: %v", err)
+	}
+	return fs
+}
+
+// BenchmarkLoadTodos compares reads served from the actor's cache with
+// decoding the file on every call.
+func BenchmarkLoadTodos(b *testing.B) {
+	for _, n := range []int{10_000, 100_000} {
+		b.Run(fmt.Sprintf("cached/%d", n), func(b *testing.B) {
+			fs := newBenchStore(b, n)
+			defer fs.Close()
+
+			for b.Loop() {
+				if _, err := fs.LoadTodos(context.Background()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("disk/%d", n), func(b *testing.B) {
+			fs := newBenchStore(b, n)
+			defer fs.Close()
+
+			for b.Loop() {
+				if _, err := fs.loadFromDisk(context.Background()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
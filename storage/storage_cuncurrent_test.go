@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
@@ -10,110 +11,131 @@ import (
 	"todo-app/todo"
 )
 
-func TestConcurrentReads(t *testing.T) {
-	t.Parallel()
-
-	tmpFile := t.TempDir() + "/concurrent_reads.json"
-	fs := NewFileStore(tmpFile)
-	defer fs.Close()
+// backends lists every Store implementation so the concurrency tests run
+// against each of them.
+var backends = []struct {
+	name     string
+	newStore func(t *testing.T) Store
+}{
+	{"file", func(t *testing.T) Store { return NewFileStore(t.TempDir() + "/todos.json") }},
+	{"journal", func(t *testing.T) Store { return NewJournalStore(t.TempDir()+"/todos.journal", 10) }},
+	{"memory", func(t *testing.T) Store { return NewMemoryStore() }},
+	{"sqlite", func(t *testing.T) Store {
+		store, err := NewSQLiteStore(t.TempDir() + "/todos.db")
+		if err != nil {
+			t.Fatalf("failed to open sqlite store: %v", err)
+		}
+		return store
+	}},
+}
 
-	initial := []todo.Item{
-		{Description: "task 1", Status: todo.NotStarted},
-		{Description: "task 2", Status: todo.Started},
-	}
-	if err := fs.SaveTodos(context.Background(), initial); err != nil {
-		t.Fatalf("setup failed: %v", err)
+func forEachBackend(t *testing.T, test func(t *testing.T, store Store)) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			t.Parallel()
+			store := b.newStore(t)
+			defer store.Close()
+			test(t, store)
+		})
 	}
+}
 
-	const numReaders = 100
-	var wg sync.WaitGroup
-	wg.Add(numReaders)
+func TestConcurrentReads(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, store Store) {
+		initial := []todo.Item{
+			{Description: "task 1", Status: todo.NotStarted},
+			{Description: "task 2", Status: todo.Started},
+		}
+		if err := store.SaveTodos(context.Background(), initial); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
 
-	errors := make(chan error, numReaders)
-	readCounts := make(chan int, numReaders)
+		const numReaders = 100
+		var wg sync.WaitGroup
+		wg.Add(numReaders)
 
-	for range numReaders {
-		go func() {
-			defer wg.Done()
+		errors := make(chan error, numReaders)
+		readCounts := make(chan int, numReaders)
 
-			todos, err := fs.LoadTodos(context.Background())
-			if err != nil {
-				errors <- err
-				return
-			}
+		for range numReaders {
+			go func() {
+				defer wg.Done()
 
-			readCounts <- len(todos)
-		}()
-	}
+				todos, err := store.LoadTodos(context.Background())
+				if err != nil {
+					errors <- err
+					return
+				}
 
-	wg.Wait()
-	close(errors)
-	close(readCounts)
+				readCounts <- len(todos)
+			}()
+		}
 
-	for err := range errors {
-		t.Errorf("concurrent read failed: %v", err)
-	}
+		wg.Wait()
+		close(errors)
+		close(readCounts)
 
-	for count := range readCounts {
-		if count != 2 {
-			t.Errorf("expected 2 todos, got %d", count)
+		for err := range errors {
+			t.Errorf("concurrent read failed: %v", err)
 		}
-	}
+
+		for count := range readCounts {
+			if count != 2 {
+				t.Errorf("expected 2 todos, got %d", count)
+			}
+		}
+	})
 }
 
 func TestConcurrentReadAndWrite(t *testing.T) {
-	t.Parallel()
-
-	tmpFile := t.TempDir() + "/concurrent_rw.json"
-	fs := NewFileStore(tmpFile)
-	defer fs.Close()
-
-	initial := []todo.Item{{Description: "initial", Status: todo.NotStarted}}
-	if err := fs.SaveTodos(context.Background(), initial); err != nil {
-		t.Fatalf("setup failed: %v", err)
-	}
+	forEachBackend(t, func(t *testing.T, store Store) {
+		initial := []todo.Item{{Description: "initial", Status: todo.NotStarted}}
+		if err := store.SaveTodos(context.Background(), initial); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
 
-	const numOperations = 100
-	var wg sync.WaitGroup
-	wg.Add(numOperations * 2)
+		const numOperations = 100
+		var wg sync.WaitGroup
+		wg.Add(numOperations * 2)
 
-	errors := make(chan error, numOperations*2)
+		errors := make(chan error, numOperations*2)
 
-	for range numOperations {
-		go func() {
-			defer wg.Done()
-			_, err := fs.LoadTodos(context.Background())
-			if err != nil {
-				errors <- err
-			}
-		}()
-	}
+		for range numOperations {
+			go func() {
+				defer wg.Done()
+				_, err := store.LoadTodos(context.Background())
+				if err != nil {
+					errors <- err
+				}
+			}()
+		}
 
-	for i := range numOperations {
-		go func() {
-			defer wg.Done()
-			todos := []todo.Item{{Description: fmt.Sprintf("task %d", i), Status: todo.NotStarted}}
-			if err := fs.SaveTodos(context.Background(), todos); err != nil {
-				errors <- err
-			}
-		}()
-	}
+		for i := range numOperations {
+			go func() {
+				defer wg.Done()
+				todos := []todo.Item{{Description: fmt.Sprintf("task %d", i), Status: todo.NotStarted}}
+				if err := store.SaveTodos(context.Background(), todos); err != nil {
+					errors <- err
+				}
+			}()
+		}
 
-	wg.Wait()
-	close(errors)
+		wg.Wait()
+		close(errors)
 
-	for err := range errors {
-		t.Errorf("operation failed: %v", err)
-	}
+		for err := range errors {
+			t.Errorf("operation failed: %v", err)
+		}
 
-	todos, err := fs.LoadTodos(context.Background())
-	if err != nil {
-		t.Fatalf("final load failed: %v", err)
-	}
+		todos, err := store.LoadTodos(context.Background())
+		if err != nil {
+			t.Fatalf("final load failed: %v", err)
+		}
 
-	if len(todos) != 1 {
-		t.Errorf("expected 1 todo after all writes, got %d", len(todos))
-	}
+		if len(todos) != 1 {
+			t.Errorf("expected 1 todo after all writes, got %d", len(todos))
+		}
+	})
 }
 
 func TestActorShutdown(t *testing.T) {
@@ -139,3 +161,195 @@ func TestActorShutdown(t *testing.T) {
 		t.Fatal("Close() hung - actor didn't shutdown")
 	}
 }
+
+func TestConcurrentUpdatesNoLostWrites(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, store Store) {
+		const numWriters = 100
+		var wg sync.WaitGroup
+		wg.Add(numWriters)
+
+		errors := make(chan error, numWriters)
+
+		for i := range numWriters {
+			go func() {
+				defer wg.Done()
+				err := store.Update(context.Background(), func(todos []todo.Item) ([]todo.Item, error) {
+					return todo.AddNewItem(todos, fmt.Sprintf("task %d", i))
+				})
+				if err != nil {
+					errors <- err
+				}
+			}()
+		}
+
+		wg.Wait()
+		close(errors)
+
+		for err := range errors {
+			t.Errorf("concurrent update failed: %v", err)
+		}
+
+		todos, err := store.LoadTodos(context.Background())
+		if err != nil {
+			t.Fatalf("final load failed: %v", err)
+		}
+
+		if len(todos) != numWriters {
+			t.Errorf("expected %d todos after all updates, got %d", numWriters, len(todos))
+		}
+	})
+}
+
+func TestClosedStoreReturnsErrStoreClosed(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		if err := store.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		if err := store.Close(); err != nil {
+			t.Fatalf("second Close failed: %v", err)
+		}
+
+		if _, err := store.LoadTodos(ctx); !errors.Is(err, ErrStoreClosed) {
+			t.Errorf("LoadTodos error = %v, want %v", err, ErrStoreClosed)
+		}
+		if err := store.SaveTodos(ctx, nil); !errors.Is(err, ErrStoreClosed) {
+			t.Errorf("SaveTodos error = %v, want %v", err, ErrStoreClosed)
+		}
+		err := store.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+			return todos, nil
+		})
+		if !errors.Is(err, ErrStoreClosed) {
+			t.Errorf("Update error = %v, want %v", err, ErrStoreClosed)
+		}
+	})
+}
+
+func TestCloseDuringCallsReturnsErrStoreClosed(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		todos := make([]todo.Item, 200)
+		for i := range todos {
+			todos[i] = todo.Item{Description: fmt.Sprintf("item %d", i), Status: todo.NotStarted}
+		}
+		if err := store.SaveTodos(ctx, todos); err != nil {
+			t.Fatalf("SaveTodos failed: %v", err)
+		}
+
+		// Keep loading until the store is closed under the callers.
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := 0; i < cap(errs); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					if _, err := store.LoadTodos(ctx); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}()
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		if err := store.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if !errors.Is(err, ErrStoreClosed) {
+				t.Errorf("LoadTodos error = %v, want %v", err, ErrStoreClosed)
+			}
+		}
+	})
+}
+
+func TestCancelledContextDoesNotBlock(t *testing.T) {
+	t.Parallel()
+
+	fs := NewFileStore(t.TempDir() + "/cancel_test.json")
+	defer fs.Close()
+
+	// Keep the actor busy so the next request cannot be accepted.
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go fs.Update(context.Background(), func(todos []todo.Item) ([]todo.Item, error) {
+		close(started)
+		<-release
+		return todos, nil
+	})
+	<-started
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := fs.LoadTodos(ctx)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("LoadTodos error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("LoadTodos ignored its context")
+	}
+}
+
+func TestCloseWaitsForInFlightWrite(t *testing.T) {
+	t.Parallel()
+
+	tmpFile := t.TempDir() + "/inflight_test.json"
+	fs := NewFileStore(tmpFile)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	updated := make(chan error, 1)
+	go func() {
+		updated <- fs.Update(context.Background(), func(todos []todo.Item) ([]todo.Item, error) {
+			close(started)
+			<-release
+			return todo.AddNewItem(todos, "test")
+		})
+	}()
+	<-started
+
+	closed := make(chan struct{})
+	go func() {
+		fs.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		t.Fatal("Close returned before the in-flight write finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-updated; err != nil {
+		t.Fatalf("in-flight Update failed: %v", err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close() hung after the in-flight write finished")
+	}
+
+	reopened := NewFileStore(tmpFile)
+	defer reopened.Close()
+	todos, err := reopened.LoadTodos(context.Background())
+	if err != nil {
+		t.Fatalf("load after close failed: %v", err)
+	}
+	if len(todos) != 1 {
+		t.Errorf("expected in-flight write to be saved, got %d todos", len(todos))
+	}
+}
@@ -2,7 +2,10 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"io"
 	"os"
+	"strings"
 	"testing"
 
 	"todo-app/todo"
@@ -11,7 +14,7 @@ import (
 func TestSaveAndLoadTodos(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
-	tmpFile := "test_todos.json"
+	tmpFile := t.TempDir() + "/test_todos.json"
 	fs := NewFileStore(tmpFile)
 	defer fs.Close()
 
@@ -39,6 +42,158 @@ func TestSaveAndLoadTodos(t *testing.T) {
 
 		t.Errorf("Loaded todos do not match: %+v", loaded)
 	}
+}
+
+func TestLoadTodosUpgradesMissingIDs(t *testing.T) {
+	ctx := context.Background()
+	tmpFile := t.TempDir() + "/legacy_todos.json"
+	legacy := `[{"Description":"test1","Status":"not started"}]`
+	if err := os.WriteFile(tmpFile, []byte(legacy), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	fs := NewFileStore(tmpFile)
+	defer fs.Close()
+
+	loaded, err := fs.LoadTodos(ctx)
+	if err != nil {
+		t.Fatalf("LoadTodos failed: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].ID == "" {
+		t.Fatalf("expected legacy item to be given an ID: %+v", loaded)
+	}
+
+	item, err := fs.LoadTodo(ctx, loaded[0].ID)
+	if err != nil {
+		t.Fatalf("LoadTodo failed: %v", err)
+	}
+	if item.Description != "test1" {
+		t.Errorf("expected description %q, got %q", "test1", item.Description)
+	}
+
+	reloaded, err := fs.LoadTodos(ctx)
+	if err != nil {
+		t.Fatalf("LoadTodos failed: %v", err)
+	}
+	if reloaded[0].ID != loaded[0].ID {
+		t.Errorf("expected upgraded ID to be persisted, got %q and %q", loaded[0].ID, reloaded[0].ID)
+	}
+}
+
+func TestUpdateFailureLeavesTodosUnchanged(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFileStore(t.TempDir() + "/update_todos.json")
+	defer fs.Close()
+
+	if err := fs.SaveTodos(ctx, []todo.Item{{ID: "1", Description: "test1", Status: todo.NotStarted}}); err != nil {
+		t.Fatalf("SaveTodos failed: %v", err)
+	}
+
+	err := fs.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+		return todo.AddNewItem(todos, "test1")
+	})
+	if !errors.Is(err, todo.ErrItemExists) {
+		t.Fatalf("expected ErrItemExists, got %v", err)
+	}
+
+	loaded, err := fs.LoadTodos(ctx)
+	if err != nil {
+		t.Fatalf("LoadTodos failed: %v", err)
+	}
+	if len(loaded) != 1 {
+		t.Errorf("expected 1 todo, got %d", len(loaded))
+	}
+}
+
+func TestFailedSaveKeepsPreviousContents(t *testing.T) {
+	ctx := context.Background()
+	tmpDir := t.TempDir()
+	fs := NewFileStore(tmpDir + "/todos.json")
+	defer fs.Close()
+
+	original := []todo.Item{{ID: "1", Description: "test1", Status: todo.NotStarted}}
+	if err := fs.SaveTodos(ctx, original); err != nil {
+		t.Fatalf("SaveTodos failed: %v", err)
+	}
+
+	errDiskFull := errors.New("disk full")
+	fs.encode = func(w io.Writer, todos []todo.Item) error {
+		w.Write([]byte(`[{"ID":"2","Descr`))
+		return errDiskFull
+	}
 
-	os.Remove(tmpFile)
+	err := fs.SaveTodos(ctx, []todo.Item{{ID: "2", Description: "test2", Status: todo.NotStarted}})
+	if !errors.Is(err, errDiskFull) {
+		t.Fatalf("expected injected error, got %v", err)
+	}
+
+	fs.encode = encodeJSON
+	loaded, err := fs.LoadTodos(ctx)
+	if err != nil {
+		t.Fatalf("LoadTodos failed after failed save: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].Description != "test1" {
+		t.Errorf("expected previous contents to survive, got %+v", loaded)
+	}
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("expected temporary files to be cleaned up, found %s", entry.Name())
+		}
+	}
+}
+
+func TestLoadTodosReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	fs := NewFileStore(t.TempDir() + "/todos.json")
+	defer fs.Close()
+
+	if err := fs.SaveTodos(ctx, []todo.Item{{ID: "1", Description: "test1", Status: todo.NotStarted, Tags: []string{"home"}}}); err != nil {
+		t.Fatalf("SaveTodos failed: %v", err)
+	}
+
+	loaded, err := fs.LoadTodos(ctx)
+	if err != nil {
+		t.Fatalf("LoadTodos failed: %v", err)
+	}
+	loaded[0].Description = "mutated"
+	loaded[0].Tags[0] = "mutated"
+
+	reloaded, err := fs.LoadTodos(ctx)
+	if err != nil {
+		t.Fatalf("LoadTodos failed: %v", err)
+	}
+	if reloaded[0].Description != "test1" || reloaded[0].Tags[0] != "home" {
+		t.Errorf("expected cached todos to be unaffected, got %+v", reloaded[0])
+	}
+}
+
+func TestLoadTodosSeesExternalChanges(t *testing.T) {
+	ctx := context.Background()
+	tmpFile := t.TempDir() + "/todos.json"
+	fs := NewFileStore(tmpFile)
+	defer fs.Close()
+
+	if err := fs.SaveTodos(ctx, []todo.Item{{ID: "1", Description: "test1", Status: todo.NotStarted}}); err != nil {
+		t.Fatalf("SaveTodos failed: %v", err)
+	}
+	if _, err := fs.LoadTodos(ctx); err != nil {
+		t.Fatalf("LoadTodos failed: %v", err)
+	}
+
+	external := `[{"ID":"1","Description":"test1","Status":"completed"},{"ID":"2","Description":"test2","Status":"not started"}]`
+	if err := os.WriteFile(tmpFile, []byte(external), 0644); err != nil {
+		t.Fatalf("external write failed: %v", err)
+	}
+
+	loaded, err := fs.LoadTodos(ctx)
+	if err != nil {
+		t.Fatalf("LoadTodos failed: %v", err)
+	}
+	if len(loaded) != 2 || loaded[0].Status != todo.Completed {
+		t.Errorf("expected external changes to be picked up, got %+v", loaded)
+	}
 }
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"todo-app/todo"
+)
+
+var ErrStoreClosed = errors.New("store is closed")
+
+// Store is implemented by every todo storage backend. Update must apply fn
+// and persist its result atomically with respect to other calls. Every
+// change made through SaveTodos or Update is recorded as events that History
+// returns, oldest first, for one item and HistoryByActor for one actor.
+// Search finds items by the words of their description and tags.
+type Store interface {
+	LoadTodos(ctx context.Context) ([]todo.Item, error)
+	SaveTodos(ctx context.Context, todos []todo.Item) error
+	Update(ctx context.Context, fn UpdateFunc) error
+	History(ctx context.Context, itemID string) ([]Event, error)
+	HistoryByActor(ctx context.Context, actor string) ([]Event, error)
+	Search(ctx context.Context, query string) ([]Match, error)
+	Close() error
+}
+
+// UpdateFunc receives the current list and returns the list to save. If it
+// returns an error nothing is written.
+type UpdateFunc func(todos []todo.Item) ([]todo.Item, error)
+
+var (
+	_ Store = (*FileStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*SQLiteStore)(nil)
+)
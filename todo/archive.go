@@ -0,0 +1,38 @@
+package todo
+
+import "time"
+
+// IsArchived reports whether a completed item has been moved out of the
+// default listings.
+func IsArchived(item Item) bool {
+	return !item.ArchivedAt.IsZero()
+}
+
+// Unarchived returns the items that have not been archived.
+func Unarchived(todos []Item) []Item {
+	var result []Item
+	for _, item := range todos {
+		if !IsArchived(item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// ArchiveCompleted archives the items completed at or before cutoff and
+// returns how many there were. Archived items keep their ID, status and
+// place in the hierarchy; reopening one takes it out of the archive.
+func ArchiveCompleted(todos []Item, cutoff time.Time) int {
+	now := Now()
+	archived := 0
+	for i := range todos {
+		item := &todos[i]
+		if item.Status != Completed || IsArchived(*item) || item.CompletedAt.IsZero() || item.CompletedAt.After(cutoff) {
+			continue
+		}
+		item.ArchivedAt = now
+		touch(item)
+		archived++
+	}
+	return archived
+}
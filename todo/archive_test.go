@@ -0,0 +1,56 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestArchiveCompleted(t *testing.T) {
+	completed := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	useClock(t, completed)
+
+	todos, err := AddNewItem(nil, "old")
+	if err != nil {
+		t.Fatalf("AddNewItem failed: %v", err)
+	}
+	for _, desc := range []string{"recent", "open"} {
+		todos, err = AddNewItem(todos, desc)
+		if err != nil {
+			t.Fatalf("AddNewItem failed: %v", err)
+		}
+	}
+	if err := UpdateStatus(todos, "old", Completed); err != nil {
+		t.Fatalf("UpdateStatus failed: %v", err)
+	}
+	useClock(t, completed.Add(48*time.Hour))
+	if err := UpdateStatus(todos, "recent", Completed); err != nil {
+		t.Fatalf("UpdateStatus failed: %v", err)
+	}
+
+	if archived := ArchiveCompleted(todos, completed.Add(24*time.Hour)); archived != 1 {
+		t.Fatalf("expected 1 item archived, got %d", archived)
+	}
+	if archived := ArchiveCompleted(todos, completed.Add(24*time.Hour)); archived != 0 {
+		t.Errorf("expected archiving twice to do nothing, got %d", archived)
+	}
+	if !IsArchived(todos[0]) {
+		t.Errorf("expected the old item to be archived, got %+v", todos[0])
+	}
+	if rest := Unarchived(todos); len(rest) != 2 || rest[0].Description != "recent" {
+		t.Errorf("expected recent and open to stay, got %+v", rest)
+	}
+
+	if err := UpdateStatusByID(todos, todos[0].ID, Started); err != nil {
+		t.Fatalf("UpdateStatusByID failed: %v", err)
+	}
+	if IsArchived(todos[0]) {
+		t.Errorf("expected reopening to unarchive the item, got %+v", todos[0])
+	}
+
+	if archived := ArchiveCompleted(todos, Now()); archived != 1 {
+		t.Fatalf("expected the recent item to be archived, got %d", archived)
+	}
+	if _, err := AddNewItem(todos, "recent"); err != nil {
+		t.Errorf("expected an archived description to be free, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package todo
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock tells the time used for CreatedAt, UpdatedAt and CompletedAt.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function such as time.Now to a Clock.
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// FixedClock always returns the same time. It keeps tests deterministic.
+type FixedClock time.Time
+
+func (c FixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+var clock atomic.Pointer[Clock]
+
+func init() {
+	SetClock(ClockFunc(time.Now))
+}
+
+// SetClock replaces the clock used by mutations and returns the previous one
+// so it can be restored.
+func SetClock(c Clock) Clock {
+	previous := clock.Swap(&c)
+	if previous == nil {
+		return nil
+	}
+	return *previous
+}
+
+// Now reads the clock set with SetClock.
+func Now() time.Time {
+	return (*clock.Load()).Now()
+}
+
+// touch records that an item changed.
+func touch(item *Item) {
+	item.UpdatedAt = Now()
+}
+
+// changeStatus sets the status of an item and keeps CompletedAt in step.
+// Only completed items stay archived.
+func changeStatus(item *Item, status string) {
+	if item.Status == status {
+		return
+	}
+	item.Status = status
+	if status == Completed {
+		item.CompletedAt = Now()
+	} else {
+		item.CompletedAt = time.Time{}
+		item.ArchivedAt = time.Time{}
+	}
+	touch(item)
+}
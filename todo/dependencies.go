@@ -0,0 +1,168 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrBlocked         = errors.New("item is blocked")
+	ErrDependencyCycle = errors.New("dependency cycle")
+)
+
+// BlockedStatuses are the statuses an item cannot move to while one of its
+// dependencies is unfinished. Add Started to also keep blocked items from
+// being started.
+var BlockedStatuses = []string{Completed}
+
+// BlockedError reports a status change refused because of unfinished
+// dependencies. It matches ErrBlocked with errors.Is.
+type BlockedError struct {
+	Description string
+	Status      string
+	Blockers    []string
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("%v: %s cannot be %s until %s is completed",
+		ErrBlocked, e.Description, e.Status, strings.Join(e.Blockers, ", "))
+}
+
+func (e *BlockedError) Unwrap() error {
+	return ErrBlocked
+}
+
+// Blockers returns the dependencies of item that are not completed yet.
+func Blockers(todos []Item, item Item) []Item {
+	var result []Item
+	for _, id := range item.DependsOn {
+		if i := indexOfID(todos, id); i >= 0 && todos[i].Status != Completed {
+			result = append(result, todos[i])
+		}
+	}
+	return result
+}
+
+func IsBlocked(todos []Item, item Item) bool {
+	return item.Status != Completed && len(Blockers(todos, item)) > 0
+}
+
+// Blocked returns the unfinished items waiting on another unfinished item.
+func Blocked(todos []Item) []Item {
+	var result []Item
+	for _, item := range todos {
+		if IsBlocked(todos, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// Ready returns the unfinished items whose dependencies are all completed.
+func Ready(todos []Item) []Item {
+	var result []Item
+	for _, item := range todos {
+		if item.Status != Completed && !IsBlocked(todos, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// AddDependencyByID records that id cannot finish before dependsOn. A
+// dependency that would close a cycle is refused.
+func AddDependencyByID(todos []Item, id, dependsOn string) error {
+	i := indexOfID(todos, id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrItemNotFound, id)
+	}
+	if indexOfID(todos, dependsOn) < 0 {
+		return fmt.Errorf("%w: %s", ErrItemNotFound, dependsOn)
+	}
+	if slices.Contains(todos[i].DependsOn, dependsOn) {
+		return nil
+	}
+	if dependsOn == id || reaches(todos, dependsOn, id) {
+		return fmt.Errorf("%w: %s already depends on %s", ErrDependencyCycle, dependsOn, id)
+	}
+
+	todos[i].DependsOn = append(slices.Clone(todos[i].DependsOn), dependsOn)
+	touch(&todos[i])
+	return nil
+}
+
+func RemoveDependencyByID(todos []Item, id, dependsOn string) error {
+	i := indexOfID(todos, id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrItemNotFound, id)
+	}
+	j := slices.Index(todos[i].DependsOn, dependsOn)
+	if j < 0 {
+		return fmt.Errorf("%w: %s does not depend on %s", ErrItemNotFound, id, dependsOn)
+	}
+
+	todos[i].DependsOn = slices.Delete(slices.Clone(todos[i].DependsOn), j, j+1)
+	if len(todos[i].DependsOn) == 0 {
+		todos[i].DependsOn = nil
+	}
+	touch(&todos[i])
+	return nil
+}
+
+// reaches reports whether to can be reached from from by following
+// dependencies.
+func reaches(todos []Item, from, to string) bool {
+	seen := map[string]bool{}
+	stack := []string{from}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == to {
+			return true
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if i := indexOfID(todos, id); i >= 0 {
+			stack = append(stack, todos[i].DependsOn...)
+		}
+	}
+	return false
+}
+
+// checkBlocked refuses moving item to status while it has unfinished
+// dependencies.
+func checkBlocked(todos []Item, item Item, status string) error {
+	if !slices.Contains(BlockedStatuses, status) {
+		return nil
+	}
+	blockers := Blockers(todos, item)
+	if len(blockers) == 0 {
+		return nil
+	}
+
+	descs := make([]string, len(blockers))
+	for i, blocker := range blockers {
+		descs[i] = blocker.Description
+	}
+	return &BlockedError{Description: item.Description, Status: status, Blockers: descs}
+}
+
+// dropDependencies removes references to items that no longer exist.
+func dropDependencies(todos []Item, removed map[string]bool) {
+	for i := range todos {
+		if !slices.ContainsFunc(todos[i].DependsOn, func(id string) bool { return removed[id] }) {
+			continue
+		}
+		todos[i].DependsOn = slices.DeleteFunc(slices.Clone(todos[i].DependsOn), func(id string) bool {
+			return removed[id]
+		})
+		if len(todos[i].DependsOn) == 0 {
+			todos[i].DependsOn = nil
+		}
+		touch(&todos[i])
+	}
+}
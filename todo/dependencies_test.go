@@ -0,0 +1,102 @@
+package todo
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestAddDependencyByID(t *testing.T) {
+	todos := []Item{
+		{ID: "a", Description: "write changelog"},
+		{ID: "b", Description: "tag repo", DependsOn: []string{"a"}},
+		{ID: "c", Description: "announce", DependsOn: []string{"b"}},
+	}
+
+	tests := []struct {
+		name      string
+		id        string
+		dependsOn string
+		wantErr   error
+	}{
+		{"new dependency", "c", "a", nil},
+		{"already present", "b", "a", nil},
+		{"self", "a", "a", ErrDependencyCycle},
+		{"closes a cycle", "a", "c", ErrDependencyCycle},
+		{"missing item", "a", "missing", ErrItemNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := AddDependencyByID(todos, tt.id, tt.dependsOn); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if !slices.Equal(todos[2].DependsOn, []string{"b", "a"}) {
+		t.Errorf("unexpected dependencies: %v", todos[2].DependsOn)
+	}
+}
+
+func TestBlockedStatusChange(t *testing.T) {
+	todos := []Item{
+		{ID: "a", Description: "write changelog", Status: NotStarted},
+		{ID: "b", Description: "tag repo", Status: NotStarted, DependsOn: []string{"a"}},
+	}
+
+	if err := UpdateStatusByID(todos, "b", Started); err != nil {
+		t.Errorf("starting a blocked item should be allowed by default: %v", err)
+	}
+
+	err := UpdateStatusByID(todos, "b", Completed)
+	var blocked *BlockedError
+	if !errors.As(err, &blocked) || !errors.Is(err, ErrBlocked) {
+		t.Fatalf("expected a BlockedError, got %v", err)
+	}
+	if !slices.Equal(blocked.Blockers, []string{"write changelog"}) {
+		t.Errorf("unexpected blockers: %v", blocked.Blockers)
+	}
+
+	if got := Blocked(todos); len(got) != 1 || got[0].ID != "b" {
+		t.Errorf("expected tag repo to be blocked, got %+v", got)
+	}
+	if got := Ready(todos); len(got) != 1 || got[0].ID != "a" {
+		t.Errorf("expected write changelog to be ready, got %+v", got)
+	}
+
+	if err := UpdateStatusByID(todos, "a", Completed); err != nil {
+		t.Fatalf("UpdateStatusByID failed: %v", err)
+	}
+	if err := UpdateStatusByID(todos, "b", Completed); err != nil {
+		t.Errorf("expected completion once the dependency is done, got %v", err)
+	}
+}
+
+func TestBlockedStatusesIncludeStarted(t *testing.T) {
+	defer func(saved []string) { BlockedStatuses = saved }(BlockedStatuses)
+	BlockedStatuses = []string{Started, Completed}
+
+	todos := []Item{
+		{ID: "a", Description: "write changelog", Status: NotStarted},
+		{ID: "b", Description: "tag repo", Status: NotStarted, DependsOn: []string{"a"}},
+	}
+	if err := UpdateStatusByID(todos, "b", Started); !errors.Is(err, ErrBlocked) {
+		t.Errorf("expected %v, got %v", ErrBlocked, err)
+	}
+}
+
+func TestRemovingDependencyUnblocks(t *testing.T) {
+	todos := []Item{
+		{ID: "a", Description: "write changelog"},
+		{ID: "b", Description: "tag repo", DependsOn: []string{"a"}},
+	}
+
+	todos, err := RemoveItemByID(todos, "a")
+	if err != nil {
+		t.Fatalf("RemoveItemByID failed: %v", err)
+	}
+	if todos[0].DependsOn != nil {
+		t.Errorf("expected the dangling dependency to be dropped, got %v", todos[0].DependsOn)
+	}
+}
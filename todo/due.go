@@ -0,0 +1,135 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidDueDate = errors.New("invalid due date")
+
+const dateLayout = "2006-01-02"
+
+// DueDate is when an item is due. It is either a calendar date, due by the
+// end of that day, or an exact time with a time zone.
+type DueDate struct {
+	Time    time.Time
+	HasTime bool
+}
+
+// ParseDueDate accepts a date such as 2026-11-01, read in the local time
+// zone, or an RFC 3339 date-time such as 2026-11-01T17:00:00+01:00.
+func ParseDueDate(s string) (DueDate, error) {
+	if t, err := time.ParseInLocation(dateLayout, s, time.Local); err == nil {
+		return DueDate{Time: t}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return DueDate{Time: t, HasTime: true}, nil
+	}
+	return DueDate{}, fmt.Errorf("%w: %q - use YYYY-MM-DD or RFC 3339", ErrInvalidDueDate, s)
+}
+
+func (d DueDate) IsZero() bool {
+	return d.Time.IsZero()
+}
+
+func (d DueDate) String() string {
+	if d.HasTime {
+		return d.Time.Format(time.RFC3339)
+	}
+	return d.Time.Format(dateLayout)
+}
+
+// Deadline is the instant after which the item is overdue.
+func (d DueDate) Deadline() time.Time {
+	if d.HasTime {
+		return d.Time
+	}
+	return d.Time.AddDate(0, 0, 1)
+}
+
+func (d DueDate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *DueDate) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidDueDate, data)
+	}
+	if s == "" {
+		*d = DueDate{}
+		return nil
+	}
+	parsed, err := ParseDueDate(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// IsOverdue reports whether an unfinished item's deadline has passed.
+func IsOverdue(item Item, now time.Time) bool {
+	if item.Due.IsZero() || item.Status == Completed {
+		return false
+	}
+	return now.After(item.Due.Deadline())
+}
+
+// IsDueToday reports whether an unfinished item is due on now's calendar day
+// and is not overdue yet.
+func IsDueToday(item Item, now time.Time) bool {
+	if item.Due.IsZero() || item.Status == Completed || IsOverdue(item, now) {
+		return false
+	}
+	due := item.Due.Time
+	if item.Due.HasTime {
+		due = due.In(now.Location())
+	}
+	y1, m1, d1 := due.Date()
+	y2, m2, d2 := now.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
+
+func Overdue(todos []Item, now time.Time) []Item {
+	var result []Item
+	for _, item := range todos {
+		if IsOverdue(item, now) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func DueToday(todos []Item, now time.Time) []Item {
+	var result []Item
+	for _, item := range todos {
+		if IsDueToday(item, now) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// UpdateDueByID sets the due date of an item. An empty due clears it.
+func UpdateDueByID(todos []Item, id, due string) error {
+	var parsed DueDate
+	if due != "" {
+		var err error
+		parsed, err = ParseDueDate(due)
+		if err != nil {
+			return err
+		}
+	}
+
+	i := indexOfID(todos, id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrItemNotFound, id)
+	}
+
+	todos[i].Due = parsed
+	touch(&todos[i])
+	return nil
+}
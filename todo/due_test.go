@@ -0,0 +1,123 @@
+package todo
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseDueDate(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantHasTime bool
+		wantString  string
+		wantErr     bool
+	}{
+		{"date", "2026-11-01", false, "2026-11-01", false},
+		{"date-time with zone", "2026-11-01T17:00:00+01:00", true, "2026-11-01T17:00:00+01:00", false},
+		{"utc date-time", "2026-11-01T17:00:00Z", true, "2026-11-01T17:00:00Z", false},
+		{"invalid", "next tuesday", false, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDueDate(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.HasTime != tt.wantHasTime {
+				t.Errorf("expected HasTime %v, got %v", tt.wantHasTime, got.HasTime)
+			}
+			if got.String() != tt.wantString {
+				t.Errorf("expected %q, got %q", tt.wantString, got.String())
+			}
+		})
+	}
+}
+
+func TestDueQueries(t *testing.T) {
+	now := time.Date(2026, 11, 1, 12, 0, 0, 0, time.Local)
+	due := func(s string) DueDate {
+		d, err := ParseDueDate(s)
+		if err != nil {
+			t.Fatalf("ParseDueDate(%q) failed: %v", s, err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name         string
+		item         Item
+		wantOverdue  bool
+		wantDueToday bool
+	}{
+		{"no due date", Item{Status: NotStarted}, false, false},
+		{"yesterday", Item{Status: NotStarted, Due: due("2026-10-31")}, true, false},
+		{"today", Item{Status: NotStarted, Due: due("2026-11-01")}, false, true},
+		{"tomorrow", Item{Status: NotStarted, Due: due("2026-11-02")}, false, false},
+		{"earlier today", Item{Status: Started, Due: DueDate{Time: now.Add(-time.Hour), HasTime: true}}, true, false},
+		{"later today", Item{Status: Started, Due: DueDate{Time: now.Add(time.Hour), HasTime: true}}, false, true},
+		{"completed", Item{Status: Completed, Due: due("2026-10-31")}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsOverdue(tt.item, now); got != tt.wantOverdue {
+				t.Errorf("expected overdue=%v, got %v", tt.wantOverdue, got)
+			}
+			if got := IsDueToday(tt.item, now); got != tt.wantDueToday {
+				t.Errorf("expected due today=%v, got %v", tt.wantDueToday, got)
+			}
+		})
+	}
+}
+
+func TestDueDateJSON(t *testing.T) {
+	item := Item{ID: "1", Description: "test", Status: NotStarted}
+	data, err := json.Marshal(item)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(data) != `{"ID":"1","Description":"test","Status":"not started"}` {
+		t.Errorf("expected no due date in JSON, got %s", data)
+	}
+
+	var decoded Item
+	if err := json.Unmarshal([]byte(`{"Description":"test","Due":"2026-11-01"}`), &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.Due.String() != "2026-11-01" {
+		t.Errorf("expected due 2026-11-01, got %q", decoded.Due)
+	}
+
+	if err := json.Unmarshal([]byte(`{"Due":"soon"}`), &decoded); err == nil {
+		t.Errorf("expected invalid due date to fail")
+	}
+}
+
+func TestUpdateDueByID(t *testing.T) {
+	todos := []Item{{ID: "1", Description: "test"}}
+
+	if err := UpdateDueByID(todos, "1", "2026-11-01"); err != nil {
+		t.Fatalf("UpdateDueByID failed: %v", err)
+	}
+	if todos[0].Due.String() != "2026-11-01" {
+		t.Errorf("expected due 2026-11-01, got %q", todos[0].Due)
+	}
+
+	if err := UpdateDueByID(todos, "1", ""); err != nil {
+		t.Fatalf("UpdateDueByID failed: %v", err)
+	}
+	if !todos[0].Due.IsZero() {
+		t.Errorf("expected due date to be cleared, got %q", todos[0].Due)
+	}
+
+	if err := UpdateDueByID(todos, "1", "whenever"); err == nil {
+		t.Errorf("expected invalid due date to fail")
+	}
+}
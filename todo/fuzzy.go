@@ -0,0 +1,133 @@
+package todo
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrAmbiguousMatch = errors.New("ambiguous description")
+
+const (
+	// maxSuggestions is how many candidates an AmbiguousMatchError lists.
+	maxSuggestions = 5
+
+	// minFuzzyLength is how many characters a description needs before it is
+	// taken to mean an item it does not match exactly. Shorter ones only
+	// give suggestions.
+	minFuzzyLength = 4
+)
+
+// AmbiguousMatchError reports a description that no item has exactly and
+// that cannot be told for certain to mean one of the candidates. It matches
+// ErrAmbiguousMatch with errors.Is.
+type AmbiguousMatchError struct {
+	Description string
+	Candidates  []string
+}
+
+func (e *AmbiguousMatchError) Error() string {
+	return fmt.Sprintf("%v: %s could be %s", ErrAmbiguousMatch, e.Description, strings.Join(e.Candidates, ", "))
+}
+
+func (e *AmbiguousMatchError) Unwrap() error {
+	return ErrAmbiguousMatch
+}
+
+// FindFuzzy is FindByDesc forgiving typos. Without an exact match, the items
+// whose description contains desc or is a few edits away from it are
+// candidates. A single candidate is returned when desc is at least
+// minFuzzyLength characters long and either appears in it as whole words or
+// is within maxEdits of it. Otherwise the candidates, closest first, are
+// reported in an AmbiguousMatchError.
+func FindFuzzy(todos []Item, list, desc string) (Item, error) {
+	list, err := NormalizeList(list)
+	if err != nil {
+		return Item{}, err
+	}
+	if i := indexOfDesc(todos, list, desc); i >= 0 {
+		return todos[i], nil
+	}
+
+	type candidate struct {
+		item     Item
+		distance int
+		certain  bool
+	}
+	want := strings.ToLower(desc)
+	length := utf8.RuneCountInString(want)
+	var candidates []candidate
+	for _, item := range todos {
+		if !findable(item, list) {
+			continue
+		}
+		distance := editDistance(want, item.Description)
+		if distance > max(1, maxEdits(want)) && (length < 3 || !strings.Contains(item.Description, want)) {
+			continue
+		}
+		certain := length >= minFuzzyLength && (distance <= maxEdits(want) || containsWords(item.Description, want))
+		candidates = append(candidates, candidate{item, distance, certain})
+	}
+
+	switch {
+	case len(candidates) == 0:
+		return Item{}, fmt.Errorf("%w: %s", ErrItemNotFound, desc)
+	case len(candidates) == 1 && candidates[0].certain:
+		return candidates[0].item, nil
+	}
+
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		return cmp.Compare(a.distance, b.distance)
+	})
+	names := make([]string, 0, maxSuggestions)
+	for _, c := range candidates[:min(len(candidates), maxSuggestions)] {
+		names = append(names, c.item.Description)
+	}
+	return Item{}, &AmbiguousMatchError{Description: desc, Candidates: names}
+}
+
+// maxEdits is how many single-character edits away from desc a description
+// may be to count as a typo of it: one per four characters.
+func maxEdits(desc string) int {
+	return utf8.RuneCountInString(desc) / 4
+}
+
+// containsWords reports whether the words of phrase appear in s next to
+// each other, as whole words.
+func containsWords(s, phrase string) bool {
+	words, want := strings.Fields(s), strings.Fields(phrase)
+	if len(want) == 0 {
+		return false
+	}
+	for i := 0; i+len(want) <= len(words); i++ {
+		if slices.Equal(words[i:i+len(want)], want) {
+			return true
+		}
+	}
+	return false
+}
+
+// editDistance is the Levenshtein distance between a and b, in runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
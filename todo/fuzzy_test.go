@@ -0,0 +1,81 @@
+package todo
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestFindFuzzy(t *testing.T) {
+	todos := []Item{
+		{ID: "1", Description: "buy milk", Status: NotStarted},
+		{ID: "2", Description: "buy silk", Status: NotStarted},
+		{ID: "3", Description: "file taxes", Status: NotStarted},
+		{ID: "4", Description: "file taxes", Status: NotStarted, List: "work"},
+		{ID: "5", Description: "renew passport", Status: NotStarted},
+		{ID: "6", Description: "q", Status: NotStarted},
+		{ID: "7", Description: "gather the receipts", Status: NotStarted},
+	}
+
+	tests := []struct {
+		name       string
+		desc       string
+		wantID     string
+		candidates []string
+		wantErr    error
+	}{
+		{"exact", "Buy Milk", "1", nil, nil},
+		{"typo", "fil taxes", "3", nil, nil},
+		{"whole words", "passport", "5", nil, nil},
+		{"whole words in the middle", "the receipts", "7", nil, nil},
+		{"close to several", "buy ilk", "", []string{"buy milk", "buy silk"}, ErrAmbiguousMatch},
+		{"substring of several", "buy", "", []string{"buy milk", "buy silk"}, ErrAmbiguousMatch},
+		{"part of a word", "pass", "", []string{"renew passport"}, ErrAmbiguousMatch},
+		{"short typo", "y", "", []string{"q"}, ErrAmbiguousMatch},
+		{"short substring", "the", "", []string{"gather the receipts"}, ErrAmbiguousMatch},
+		{"too short for a substring", "ax", "", nil, ErrItemNotFound},
+		{"too far", "walk dog", "", nil, ErrItemNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, err := FindFuzzy(todos, "", tt.desc)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				var ambiguous *AmbiguousMatchError
+				if errors.As(err, &ambiguous) && !slices.Equal(ambiguous.Candidates, tt.candidates) {
+					t.Errorf("expected candidates %v, got %v", tt.candidates, ambiguous.Candidates)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FindFuzzy failed: %v", err)
+			}
+			if item.ID != tt.wantID {
+				t.Errorf("expected item %s, got %+v", tt.wantID, item)
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"milk", "", 4},
+		{"milk", "silk", 1},
+		{"mlk", "milk", 1},
+		{"taxes", "texas", 2},
+		{"café", "cafe", 1},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrInvalidList  = errors.New("invalid list name")
+	ErrListNotFound = errors.New("list not found")
+	ErrListExists   = errors.New("list already exists")
+)
+
+// DefaultList names the list that items without a list belong to. Lists have
+// no record of their own: a list exists while it holds at least one item.
+const DefaultList = "default"
+
+// NormalizeList lowercases a list name and drops surrounding space. The
+// default list is stored as the empty name, so "" and "Default" are the same
+// list.
+func NormalizeList(name string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(name))
+	if strings.ContainsAny(normalized, "/?#\t\n") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidList, name)
+	}
+	if normalized == DefaultList {
+		return "", nil
+	}
+	return normalized, nil
+}
+
+// ListName is the name of the list the item belongs to, for display.
+func ListName(item Item) string {
+	if item.List == "" {
+		return DefaultList
+	}
+	return item.List
+}
+
+func InList(item Item, list string) bool {
+	normalized, err := NormalizeList(list)
+	if err != nil {
+		return false
+	}
+	return item.List == normalized
+}
+
+func FilterByList(todos []Item, list string) []Item {
+	var result []Item
+	for _, item := range todos {
+		if InList(item, list) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// ListSummary counts the items of one list.
+type ListSummary struct {
+	Name      string
+	Count     int
+	Completed int
+}
+
+// Lists returns a summary of every list that holds items, ordered by name.
+func Lists(todos []Item) []ListSummary {
+	var lists []ListSummary
+	for _, item := range todos {
+		name := ListName(item)
+		i := slices.IndexFunc(lists, func(l ListSummary) bool { return l.Name == name })
+		if i < 0 {
+			lists = append(lists, ListSummary{Name: name})
+			i = len(lists) - 1
+		}
+		lists[i].Count++
+		if item.Status == Completed {
+			lists[i].Completed++
+		}
+	}
+	slices.SortFunc(lists, func(a, b ListSummary) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return lists
+}
+
+// RenameList moves every item of from into to, which must not hold items
+// yet.
+func RenameList(todos []Item, from, to string) error {
+	from, err := NormalizeList(from)
+	if err != nil {
+		return err
+	}
+	to, err = NormalizeList(to)
+	if err != nil {
+		return err
+	}
+
+	if !slices.ContainsFunc(todos, func(item Item) bool { return item.List == from }) {
+		return fmt.Errorf("%w: %s", ErrListNotFound, displayList(from))
+	}
+	if from == to {
+		return nil
+	}
+	if slices.ContainsFunc(todos, func(item Item) bool { return item.List == to }) {
+		return fmt.Errorf("%w: %s", ErrListExists, displayList(to))
+	}
+
+	for i := range todos {
+		if todos[i].List == from {
+			todos[i].List = to
+			touch(&todos[i])
+		}
+	}
+	return nil
+}
+
+// RemoveList removes a list together with all of its items.
+func RemoveList(todos []Item, name string) ([]Item, error) {
+	list, err := NormalizeList(name)
+	if err != nil {
+		return todos, err
+	}
+
+	removed := map[string]bool{}
+	remaining := make([]Item, 0, len(todos))
+	for _, item := range todos {
+		if item.List == list {
+			removed[item.ID] = true
+			continue
+		}
+		remaining = append(remaining, item)
+	}
+	if len(remaining) == len(todos) {
+		return todos, fmt.Errorf("%w: %s", ErrListNotFound, displayList(list))
+	}
+	dropDependencies(remaining, removed)
+	return remaining, nil
+}
+
+// MoveToListByID moves an item and its subtasks into another list. None of
+// their descriptions may be taken in the target list. A subtask that is moved
+// on its own leaves its parent and becomes a top-level item.
+func MoveToListByID(todos []Item, id, list string) error {
+	list, err := NormalizeList(list)
+	if err != nil {
+		return err
+	}
+
+	i := indexOfID(todos, id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrItemNotFound, id)
+	}
+	if todos[i].List == list {
+		return nil
+	}
+
+	ids := subtree(todos, id)
+	for _, item := range todos {
+		if ids[item.ID] && indexOfDesc(todos, list, item.Description) >= 0 {
+			return fmt.Errorf("%w: %s in list %s", ErrItemExists, item.Description, displayList(list))
+		}
+	}
+
+	for j := range todos {
+		if ids[todos[j].ID] {
+			todos[j].List = list
+			touch(&todos[j])
+		}
+	}
+	parentID := todos[i].ParentID
+	todos[i].ParentID = ""
+	syncAncestors(todos, parentID)
+	return nil
+}
+
+func displayList(list string) string {
+	return ListName(Item{List: list})
+}
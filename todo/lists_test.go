@@ -0,0 +1,151 @@
+package todo
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestNormalizeList(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"plain", "work", "work", false},
+		{"case and space", "  Home Projects ", "home projects", false},
+		{"empty is default", "", "", false},
+		{"default by name", "Default", "", false},
+		{"slash", "a/b", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeList(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAddItemUniquePerList(t *testing.T) {
+	todos, err := AddItem(nil, Item{Description: "standup"})
+	if err != nil {
+		t.Fatalf("AddItem failed: %v", err)
+	}
+
+	todos, err = AddItem(todos, Item{Description: "Standup", List: "Work"})
+	if err != nil {
+		t.Fatalf("same description in another list should be allowed: %v", err)
+	}
+	if todos[1].List != "work" {
+		t.Errorf("expected list to be normalised to %q, got %q", "work", todos[1].List)
+	}
+
+	if _, err := AddItem(todos, Item{Description: "standup", List: "work"}); !errors.Is(err, ErrItemExists) {
+		t.Errorf("expected %v, got %v", ErrItemExists, err)
+	}
+
+	item, err := FindByDesc(todos, "work", "standup")
+	if err != nil {
+		t.Fatalf("FindByDesc failed: %v", err)
+	}
+	if item.ID != todos[1].ID {
+		t.Errorf("expected the item from the work list, got %+v", item)
+	}
+}
+
+func TestLists(t *testing.T) {
+	todos := []Item{
+		{ID: "1", Description: "a", Status: Completed, List: "work"},
+		{ID: "2", Description: "b", Status: NotStarted},
+		{ID: "3", Description: "c", Status: NotStarted, List: "work"},
+	}
+
+	got := Lists(todos)
+	want := []ListSummary{
+		{Name: DefaultList, Count: 1},
+		{Name: "work", Count: 2, Completed: 1},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestRenameList(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		wantErr error
+	}{
+		{"rename", "work", "office", nil},
+		{"into default", "work", "default", ErrListExists},
+		{"missing", "garden", "office", ErrListNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos := []Item{
+				{ID: "1", Description: "a", List: "work"},
+				{ID: "2", Description: "b"},
+			}
+
+			err := RenameList(todos, tt.from, tt.to)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && todos[0].List != tt.to {
+				t.Errorf("expected item to be in %q, got %q", tt.to, todos[0].List)
+			}
+		})
+	}
+}
+
+func TestRemoveList(t *testing.T) {
+	todos := []Item{
+		{ID: "1", Description: "a", List: "work"},
+		{ID: "2", Description: "b"},
+		{ID: "3", Description: "c", List: "work"},
+	}
+
+	remaining, err := RemoveList(todos, "Work")
+	if err != nil {
+		t.Fatalf("RemoveList failed: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].ID != "2" {
+		t.Errorf("expected only the default list item, got %+v", remaining)
+	}
+
+	if _, err := RemoveList(remaining, "work"); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("expected %v, got %v", ErrListNotFound, err)
+	}
+}
+
+func TestMoveToListByID(t *testing.T) {
+	todos := []Item{
+		{ID: "1", Description: "a"},
+		{ID: "2", Description: "a", List: "work"},
+		{ID: "3", Description: "b"},
+	}
+
+	if err := MoveToListByID(todos, "1", "work"); !errors.Is(err, ErrItemExists) {
+		t.Errorf("expected %v, got %v", ErrItemExists, err)
+	}
+
+	if err := MoveToListByID(todos, "3", "work"); err != nil {
+		t.Fatalf("MoveToListByID failed: %v", err)
+	}
+	if todos[2].List != "work" {
+		t.Errorf("expected item to move to work, got %q", todos[2].List)
+	}
+
+	if err := MoveToListByID(todos, "missing", "work"); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("expected %v, got %v", ErrItemNotFound, err)
+	}
+}
@@ -3,85 +3,346 @@ package todo
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 var (
-	ErrItemIsEmpty   = errors.New("item description cannot be empty")
-	ErrItemExists    = errors.New("item already exists")
-	ErrItemNotFound  = errors.New("item not found")
-	ErrInvalidStatus = errors.New("invalid status")
-	ErrDuplicateDesc = errors.New("new description already exists")
+	ErrItemIsEmpty     = errors.New("item description cannot be empty")
+	ErrItemExists      = errors.New("item already exists")
+	ErrItemNotFound    = errors.New("item not found")
+	ErrInvalidStatus   = errors.New("invalid status")
+	ErrDuplicateDesc   = errors.New("new description already exists")
+	ErrInvalidPriority = errors.New("invalid priority")
 )
 
+const timestampLayout = "2006-01-02 15:04"
+
+// PrintTodos prints one item per line, with subtasks indented below their
+// parent.
 func PrintTodos(todos []Item) {
-	for _, element := range todos {
-		fmt.Printf("%s: %s\n", element.Description, element.Status)
+	printNodes(Tree(todos), 0)
+}
+
+// PrintFlat prints one item per line in the given order, without nesting
+// subtasks below their parent.
+func PrintFlat(todos []Item) {
+	nodes := make([]Node, len(todos))
+	for i, item := range todos {
+		nodes[i] = Node{Item: item}
+	}
+	printNodes(nodes, 0)
+}
+
+func printNodes(nodes []Node, depth int) {
+	for _, element := range nodes {
+		fmt.Printf("%s%s: %s", strings.Repeat("  ", depth), element.Description, element.Status)
+		if element.Priority != "" {
+			fmt.Printf(" [%s]", element.Priority)
+		}
+		for _, tag := range element.Tags {
+			fmt.Printf(" #%s", tag)
+		}
+		if !element.Due.IsZero() {
+			fmt.Printf(" (due %s)", element.Due)
+		}
+		if element.Recurrence != "" {
+			fmt.Printf(" (repeats %s)", element.Recurrence)
+		}
+		if element.List != "" {
+			fmt.Printf(" @%s", element.List)
+		}
+		if !element.CreatedAt.IsZero() {
+			fmt.Printf(" (created %s, updated %s", element.CreatedAt.Format(timestampLayout), element.UpdatedAt.Format(timestampLayout))
+			if !element.CompletedAt.IsZero() {
+				fmt.Printf(", completed %s", element.CompletedAt.Format(timestampLayout))
+			}
+			if !element.ArchivedAt.IsZero() {
+				fmt.Printf(", archived %s", element.ArchivedAt.Format(timestampLayout))
+			}
+			if !element.DeletedAt.IsZero() {
+				fmt.Printf(", deleted %s", element.DeletedAt.Format(timestampLayout))
+			}
+			fmt.Print(")")
+		}
+		fmt.Println()
+		printNodes(element.Subtasks, depth+1)
+	}
+}
+
+func NewID() string {
+	return uuid.New().String()
+}
+
+// EnsureIDs assigns a fresh ID to every item that lacks one and reports
+// whether any item was changed. It is used to upgrade lists saved before
+// items carried IDs.
+func EnsureIDs(todos []Item) bool {
+	changed := false
+	for i := range todos {
+		if todos[i].ID == "" {
+			todos[i].ID = NewID()
+			changed = true
+		}
+	}
+	return changed
+}
+
+func FindByID(todos []Item, id string) (Item, error) {
+	i := indexOfID(todos, id)
+	if i < 0 {
+		return Item{}, fmt.Errorf("%w: %s", ErrItemNotFound, id)
 	}
+	return todos[i], nil
+}
+
+// FindByDesc looks up desc in the named list; an empty name means the
+// default list.
+func FindByDesc(todos []Item, list, desc string) (Item, error) {
+	list, err := NormalizeList(list)
+	if err != nil {
+		return Item{}, err
+	}
+
+	i := indexOfDesc(todos, list, desc)
+	if i < 0 {
+		return Item{}, fmt.Errorf("%w: %s", ErrItemNotFound, desc)
+	}
+	return todos[i], nil
 }
 
 func AddNewItem(todos []Item, desc string) ([]Item, error) {
+	return AddItem(todos, Item{Description: desc})
+}
+
+// AddItem appends a new item built from draft. The description is
+// normalised and must be unique within the item's list, the status defaults
+// to not started, the priority to medium and a fresh ID is assigned. A draft
+// with a ParentID becomes a subtask in its parent's list.
+func AddItem(todos []Item, draft Item) ([]Item, error) {
+	desc := draft.Description
 	if len(desc) == 0 {
 		return todos, fmt.Errorf("%w: %s", ErrItemIsEmpty, desc)
 	}
+	list, err := NormalizeList(draft.List)
+	if err != nil {
+		return todos, err
+	}
+	if draft.ParentID != "" {
+		p := indexOfID(todos, draft.ParentID)
+		if p < 0 {
+			return todos, fmt.Errorf("%w: parent %s", ErrItemNotFound, draft.ParentID)
+		}
+		list = todos[p].List
+	}
 	lowerCaseDesc := strings.ToLower(desc)
-	for _, item := range todos {
-		if item.Description == lowerCaseDesc {
-			return todos, fmt.Errorf("%w: %s", ErrItemExists, desc)
+	if indexOfDesc(todos, list, lowerCaseDesc) >= 0 {
+		return todos, fmt.Errorf("%w: %s", ErrItemExists, desc)
+	}
+
+	status := NotStarted
+	if draft.Status != "" {
+		if !IsValidStatus(draft.Status) {
+			return todos, fmt.Errorf("%w: %s", ErrInvalidStatus, draft.Status)
 		}
+		status = strings.ToLower(draft.Status)
 	}
 
-	return append(todos, Item{Description: lowerCaseDesc, Status: NotStarted}), nil
-}
+	priority := PriorityMedium
+	if draft.Priority != "" {
+		if !IsValidPriority(draft.Priority) {
+			return todos, fmt.Errorf("%w: %s", ErrInvalidPriority, draft.Priority)
+		}
+		priority = strings.ToLower(draft.Priority)
+	}
 
-func RemoveItem(todos []Item, desc string) ([]Item, error) {
-	var updatedTodos []Item
-	for _, element := range todos {
-		if element.Description != strings.ToLower(desc) {
-			updatedTodos = append(updatedTodos, element)
+	tags, err := NormalizeTags(draft.Tags)
+	if err != nil {
+		return todos, err
+	}
+
+	var recurrence string
+	if draft.Recurrence != "" {
+		r, err := ParseRecurrence(draft.Recurrence)
+		if err != nil {
+			return todos, err
 		}
+		recurrence = r.String()
 	}
 
-	if len(updatedTodos) == len(todos) {
+	var dependsOn []string
+	for _, id := range draft.DependsOn {
+		if indexOfID(todos, id) < 0 {
+			return todos, fmt.Errorf("%w: %s", ErrItemNotFound, id)
+		}
+		if !slices.Contains(dependsOn, id) {
+			dependsOn = append(dependsOn, id)
+		}
+	}
+
+	item := draft.Clone()
+	item.ID = NewID()
+	item.Tags = tags
+	item.Description = lowerCaseDesc
+	item.Status = status
+	item.Priority = priority
+	item.List = list
+	item.DependsOn = dependsOn
+	item.Recurrence = recurrence
+	item.NextID = ""
+	item.CreatedAt = Now()
+	item.UpdatedAt = item.CreatedAt
+	item.CompletedAt = time.Time{}
+	if status == Completed {
+		item.CompletedAt = item.CreatedAt
+	}
+	if err := checkBlocked(todos, item, status); err != nil {
+		return todos, err
+	}
+	next := append(slices.Clone(todos), item)
+	if err := syncAncestors(next, item.ParentID); err != nil {
+		return todos, err
+	}
+	return next, nil
+}
+
+// RemoveItem removes desc, and its subtasks, from the default list.
+func RemoveItem(todos []Item, desc string) ([]Item, error) {
+	i := indexOfDesc(todos, "", desc)
+	if i < 0 {
 		return todos, fmt.Errorf("%w: %s", ErrItemNotFound, desc)
 	}
 
-	return updatedTodos, nil
+	return removeTree(todos, i), nil
+}
+
+// RemoveItemByID removes an item together with its subtasks.
+func RemoveItemByID(todos []Item, id string) ([]Item, error) {
+	i := indexOfID(todos, id)
+	if i < 0 {
+		return todos, fmt.Errorf("%w: %s", ErrItemNotFound, id)
+	}
+
+	return removeTree(todos, i), nil
 }
 
+// UpdateStatus sets the status of desc in the default list.
 func UpdateStatus(todos []Item, desc, status string) error {
 	if !IsValidStatus(status) {
 		return fmt.Errorf("%w: %s", ErrInvalidStatus, status)
 	}
 
-	for i := range todos {
-		if todos[i].Description == strings.ToLower(desc) {
-			todos[i].Status = strings.ToLower(status)
-			return nil
-		}
+	i := indexOfDesc(todos, "", desc)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrItemNotFound, desc)
+	}
+
+	return setStatus(todos, i, strings.ToLower(status))
+}
+
+func UpdateStatusByID(todos []Item, id, status string) error {
+	if !IsValidStatus(status) {
+		return fmt.Errorf("%w: %s", ErrInvalidStatus, status)
+	}
+
+	i := indexOfID(todos, id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrItemNotFound, id)
+	}
+
+	return setStatus(todos, i, strings.ToLower(status))
+}
+
+func UpdatePriorityByID(todos []Item, id, priority string) error {
+	if !IsValidPriority(priority) {
+		return fmt.Errorf("%w: %s", ErrInvalidPriority, priority)
+	}
+
+	i := indexOfID(todos, id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrItemNotFound, id)
 	}
 
-	return fmt.Errorf("%w: %s", ErrItemNotFound, desc)
+	todos[i].Priority = strings.ToLower(priority)
+	touch(&todos[i])
+	return nil
 }
 
+// UpdateDesc renames oldDesc in the default list.
 func UpdateDesc(todos []Item, oldDesc string, newDesc string) error {
-	found := false
-	for i := range todos {
-		if todos[i].Description == strings.ToLower(newDesc) {
-			return fmt.Errorf("%w: %s", ErrDuplicateDesc, newDesc)
-		}
+	if indexOfDesc(todos, "", newDesc) >= 0 {
+		return fmt.Errorf("%w: %s", ErrDuplicateDesc, newDesc)
+	}
+
+	i := indexOfDesc(todos, "", oldDesc)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrItemNotFound, oldDesc)
+	}
+
+	todos[i].Description = strings.ToLower(newDesc)
+	touch(&todos[i])
+	return nil
+}
+
+// UpdateNotesByID replaces the notes of an item; empty notes remove them.
+func UpdateNotesByID(todos []Item, id, notes string) error {
+	i := indexOfID(todos, id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrItemNotFound, id)
 	}
 
+	todos[i].Notes = strings.TrimSpace(notes)
+	touch(&todos[i])
+	return nil
+}
+
+func UpdateDescByID(todos []Item, id string, newDesc string) error {
+	i := indexOfID(todos, id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrItemNotFound, id)
+	}
+
+	if indexOfDesc(todos, todos[i].List, newDesc) >= 0 {
+		return fmt.Errorf("%w: %s", ErrDuplicateDesc, newDesc)
+	}
+
+	todos[i].Description = strings.ToLower(newDesc)
+	touch(&todos[i])
+	return nil
+}
+
+func indexOfID(todos []Item, id string) int {
+	if id == "" {
+		return -1
+	}
 	for i := range todos {
-		if todos[i].Description == strings.ToLower(oldDesc) {
-			found = true
-			todos[i].Description = strings.ToLower(newDesc)
+		if todos[i].ID == id {
+			return i
 		}
 	}
+	return -1
+}
 
-	if found {
-		return nil
+// indexOfDesc finds desc within list, which must already be normalised.
+// Occurrences of a recurring item that have been followed by a new one are
+// history and do not count, and neither do archived items or those in the
+// trash.
+func indexOfDesc(todos []Item, list, desc string) int {
+	lowerCaseDesc := strings.ToLower(desc)
+	for i := range todos {
+		item := todos[i]
+		if findable(item, list) && item.Description == lowerCaseDesc {
+			return i
+		}
 	}
+	return -1
+}
 
-	return fmt.Errorf("%w: %s", ErrItemNotFound, oldDesc)
+// findable reports whether item counts when looking up descriptions in
+// list, as described for indexOfDesc.
+func findable(item Item, list string) bool {
+	return item.List == list && item.NextID == "" && !IsArchived(item) && !IsTrashed(item)
 }
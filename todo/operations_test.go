@@ -2,6 +2,7 @@ package todo
 
 import (
 	"testing"
+	"time"
 )
 
 func TestAddNewItem(t *testing.T) {
@@ -37,6 +38,9 @@ func TestAddNewItem(t *testing.T) {
 			if len(got) > 0 && got[0].Status != tt.wantStatus {
 				t.Errorf("expected status %q, got %q", tt.wantStatus, got[0].Status)
 			}
+			if !tt.wantErr && got[len(got)-1].ID == "" {
+				t.Errorf("expected new item to have an ID")
+			}
 		})
 	}
 }
@@ -80,7 +84,7 @@ func TestUpdateStatus(t *testing.T) {
 		{"valid update", []Item{{Description: "test", Status: NotStarted}}, "test", Started, Started, false},
 		{"case-insensitive match", []Item{{Description: "test", Status: NotStarted}}, "TEST", Started, Started, false},
 		{"invalid status", []Item{{Description: "test", Status: NotStarted}}, "test", "invalid status", NotStarted, true},
-		{"absent item", []Item{{"test", NotStarted}}, "nope", Completed, NotStarted, true},
+		{"absent item", []Item{{Description: "test", Status: NotStarted}}, "nope", Completed, NotStarted, true},
 	}
 
 	for _, tt := range tests {
@@ -106,10 +110,10 @@ func TestUpdateDesc(t *testing.T) {
 		wantDesc   string
 		wantErr    bool
 	}{
-		{"valid update", []Item{{"test1", NotStarted}}, "test1", "test2", "test2", false},
-		{"case-insensitive update", []Item{{"test1", NotStarted}}, "TeSt1", "TEst2", "test2", false},
-		{"absent item", []Item{{"test1", NotStarted}}, "test2", "test3", "test1", true},
-		{"duplicate new desc", []Item{{"test1", NotStarted}, {"test2", NotStarted}}, "test1", "test2", "test1", true},
+		{"valid update", []Item{{Description: "test1", Status: NotStarted}}, "test1", "test2", "test2", false},
+		{"case-insensitive update", []Item{{Description: "test1", Status: NotStarted}}, "TeSt1", "TEst2", "test2", false},
+		{"absent item", []Item{{Description: "test1", Status: NotStarted}}, "test2", "test3", "test1", true},
+		{"duplicate new desc", []Item{{Description: "test1", Status: NotStarted}, {Description: "test2", Status: NotStarted}}, "test1", "test2", "test1", true},
 	}
 
 	for _, tt := range tests {
@@ -125,3 +129,233 @@ func TestUpdateDesc(t *testing.T) {
 		})
 	}
 }
+
+func TestEnsureIDs(t *testing.T) {
+	todos := []Item{{ID: "keep", Description: "test1"}, {Description: "test2"}}
+
+	if !EnsureIDs(todos) {
+		t.Fatalf("expected EnsureIDs to report a change")
+	}
+	if todos[0].ID != "keep" {
+		t.Errorf("expected existing ID to be kept, got %q", todos[0].ID)
+	}
+	if todos[1].ID == "" {
+		t.Errorf("expected missing ID to be assigned")
+	}
+	if EnsureIDs(todos) {
+		t.Errorf("expected no change on second call")
+	}
+}
+
+func TestRemoveItemByID(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []Item
+		id          string
+		wantErr     bool
+		expectedLen int
+	}{
+		{"remove existing", []Item{{ID: "1", Description: "test"}}, "1", false, 0},
+		{"keeps similar items", []Item{{ID: "1", Description: "test"}, {ID: "2", Description: "test"}}, "2", false, 1},
+		{"remove absent", []Item{{ID: "1", Description: "test"}}, "2", true, 1},
+		{"empty id", []Item{{Description: "test"}}, "", true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RemoveItemByID(tt.input, tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+
+			if len(got) != tt.expectedLen {
+				t.Errorf("expected length %d, got %d", tt.expectedLen, len(got))
+			}
+		})
+	}
+}
+
+func TestUpdateByID(t *testing.T) {
+	tests := []struct {
+		name       string
+		todos      []Item
+		id         string
+		newStatus  string
+		newDesc    string
+		wantStatus string
+		wantDesc   string
+		wantErr    bool
+	}{
+		{"status update", []Item{{ID: "1", Description: "test1", Status: NotStarted}}, "1", Started, "", Started, "test1", false},
+		{"description update", []Item{{ID: "1", Description: "test1", Status: NotStarted}}, "1", "", "TEst2", NotStarted, "test2", false},
+		{"invalid status", []Item{{ID: "1", Description: "test1", Status: NotStarted}}, "1", "invalid status", "", NotStarted, "test1", true},
+		{"absent item", []Item{{ID: "1", Description: "test1", Status: NotStarted}}, "2", Completed, "", NotStarted, "test1", true},
+		{"duplicate new desc", []Item{{ID: "1", Description: "test1"}, {ID: "2", Description: "test2"}}, "1", "", "test2", "", "test1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.newStatus != "" {
+				err = UpdateStatusByID(tt.todos, tt.id, tt.newStatus)
+			} else {
+				err = UpdateDescByID(tt.todos, tt.id, tt.newDesc)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+
+			if tt.todos[0].Status != tt.wantStatus {
+				t.Errorf("expected status %q, got %q", tt.wantStatus, tt.todos[0].Status)
+			}
+			if tt.todos[0].Description != tt.wantDesc {
+				t.Errorf("expected description %q, got %q", tt.wantDesc, tt.todos[0].Description)
+			}
+		})
+	}
+}
+
+func TestAddItem(t *testing.T) {
+	tests := []struct {
+		name         string
+		draft        Item
+		wantStatus   string
+		wantPriority string
+		wantErr      bool
+	}{
+		{"defaults", Item{Description: "test"}, NotStarted, PriorityMedium, false},
+		{"explicit values", Item{Description: "test", Status: "Started", Priority: "URGENT"}, Started, PriorityUrgent, false},
+		{"invalid status", Item{Description: "test", Status: "paused"}, "", "", true},
+		{"invalid priority", Item{Description: "test", Priority: "whenever"}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AddItem([]Item{}, tt.draft)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got[0].Status != tt.wantStatus {
+				t.Errorf("expected status %q, got %q", tt.wantStatus, got[0].Status)
+			}
+			if got[0].Priority != tt.wantPriority {
+				t.Errorf("expected priority %q, got %q", tt.wantPriority, got[0].Priority)
+			}
+		})
+	}
+}
+
+func TestUpdatePriorityByID(t *testing.T) {
+	tests := []struct {
+		name         string
+		id           string
+		priority     string
+		wantPriority string
+		wantErr      bool
+	}{
+		{"valid update", "1", PriorityHigh, PriorityHigh, false},
+		{"case-insensitive", "1", "Low", PriorityLow, false},
+		{"invalid priority", "1", "asap", PriorityMedium, true},
+		{"absent item", "2", PriorityHigh, PriorityMedium, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos := []Item{{ID: "1", Description: "test", Priority: PriorityMedium}}
+			err := UpdatePriorityByID(todos, tt.id, tt.priority)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+
+			if todos[0].Priority != tt.wantPriority {
+				t.Errorf("expected priority %q, got %q", tt.wantPriority, todos[0].Priority)
+			}
+		})
+	}
+}
+
+// useClock fixes the time seen by mutations for the rest of the test.
+func useClock(t *testing.T, now time.Time) {
+	t.Helper()
+	previous := SetClock(FixedClock(now))
+	t.Cleanup(func() { SetClock(previous) })
+}
+
+func TestTimestamps(t *testing.T) {
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	later := created.Add(time.Hour)
+
+	tests := []struct {
+		name          string
+		update        func(todos []Item, id string) error
+		wantUpdated   time.Time
+		wantCompleted time.Time
+	}{
+		{"status to started", func(todos []Item, id string) error {
+			return UpdateStatusByID(todos, id, Started)
+		}, later, time.Time{}},
+		{"status to completed", func(todos []Item, id string) error {
+			return UpdateStatusByID(todos, id, Completed)
+		}, later, later},
+		{"same status", func(todos []Item, id string) error {
+			return UpdateStatusByID(todos, id, NotStarted)
+		}, created, time.Time{}},
+		{"description", func(todos []Item, id string) error {
+			return UpdateDescByID(todos, id, "renamed")
+		}, later, time.Time{}},
+		{"priority", func(todos []Item, id string) error {
+			return UpdatePriorityByID(todos, id, PriorityHigh)
+		}, later, time.Time{}},
+		{"tags", func(todos []Item, id string) error {
+			return AddTagsByID(todos, id, "ops")
+		}, later, time.Time{}},
+		{"failed update", func(todos []Item, id string) error {
+			return UpdateStatusByID(todos, id, "invalid status")
+		}, created, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useClock(t, created)
+			todos, err := AddNewItem(nil, "test")
+			if err != nil {
+				t.Fatalf("AddNewItem failed: %v", err)
+			}
+			if !todos[0].CreatedAt.Equal(created) || !todos[0].UpdatedAt.Equal(created) {
+				t.Fatalf("expected new item to be stamped %v, got %+v", created, todos[0])
+			}
+
+			useClock(t, later)
+			tt.update(todos, todos[0].ID)
+
+			if !todos[0].CreatedAt.Equal(created) {
+				t.Errorf("expected CreatedAt %v, got %v", created, todos[0].CreatedAt)
+			}
+			if !todos[0].UpdatedAt.Equal(tt.wantUpdated) {
+				t.Errorf("expected UpdatedAt %v, got %v", tt.wantUpdated, todos[0].UpdatedAt)
+			}
+			if !todos[0].CompletedAt.Equal(tt.wantCompleted) {
+				t.Errorf("expected CompletedAt %v, got %v", tt.wantCompleted, todos[0].CompletedAt)
+			}
+		})
+	}
+}
+
+func TestReopeningClearsCompletedAt(t *testing.T) {
+	useClock(t, time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC))
+	todos := []Item{{ID: "1", Description: "test", Status: NotStarted}}
+
+	if err := UpdateStatusByID(todos, "1", Completed); err != nil {
+		t.Fatalf("UpdateStatusByID failed: %v", err)
+	}
+	if err := UpdateStatusByID(todos, "1", Started); err != nil {
+		t.Fatalf("UpdateStatusByID failed: %v", err)
+	}
+	if !todos[0].CompletedAt.IsZero() {
+		t.Errorf("expected CompletedAt to be cleared, got %v", todos[0].CompletedAt)
+	}
+}
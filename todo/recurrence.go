@@ -0,0 +1,255 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRecurrence = errors.New("invalid recurrence")
+
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Recurrence is how often an item comes back. It is a subset of the iCalendar
+// RRULE: a frequency, an interval, and optionally weekdays for weekly rules or
+// a day of the month for monthly rules.
+type Recurrence struct {
+	Freq     string
+	Interval int
+	Weekdays []time.Weekday
+	MonthDay int
+}
+
+// ParseRecurrence accepts an RRULE such as FREQ=WEEKLY;BYDAY=MO,TH or one of
+// the shorthands "daily", "weekly", "monthly", "every 3 days",
+// "weekly on mon,thu" and "monthly on 15".
+func ParseRecurrence(s string) (Recurrence, error) {
+	input := strings.TrimSpace(s)
+	lower := strings.ToLower(input)
+
+	var r Recurrence
+	var err error
+	switch {
+	case strings.Contains(input, "="):
+		r, err = parseRRule(strings.TrimPrefix(strings.ToUpper(input), "RRULE:"))
+	case lower == "daily":
+		r = Recurrence{Freq: FreqDaily}
+	case lower == "weekly":
+		r = Recurrence{Freq: FreqWeekly}
+	case lower == "monthly":
+		r = Recurrence{Freq: FreqMonthly}
+	case strings.HasPrefix(lower, "every ") && strings.HasSuffix(lower, " days"):
+		r.Freq = FreqDaily
+		r.Interval, err = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(lower, "every "), " days"))
+	case strings.HasPrefix(lower, "weekly on "):
+		r.Freq = FreqWeekly
+		r.Weekdays, err = parseWeekdays(strings.Split(strings.TrimPrefix(lower, "weekly on "), ","))
+	case strings.HasPrefix(lower, "monthly on "):
+		r.Freq = FreqMonthly
+		r.MonthDay, err = strconv.Atoi(strings.TrimPrefix(lower, "monthly on "))
+	default:
+		err = errors.New("unknown rule")
+	}
+	if err != nil {
+		return Recurrence{}, fmt.Errorf("%w: %q: %v", ErrInvalidRecurrence, s, err)
+	}
+
+	if r.Interval == 0 {
+		r.Interval = 1
+	}
+	if r.Interval < 0 || r.MonthDay < 0 || r.MonthDay > 31 {
+		return Recurrence{}, fmt.Errorf("%w: %q", ErrInvalidRecurrence, s)
+	}
+	if (len(r.Weekdays) > 0 && r.Freq != FreqWeekly) || (r.MonthDay > 0 && r.Freq != FreqMonthly) {
+		return Recurrence{}, fmt.Errorf("%w: %q: BYDAY needs FREQ=WEEKLY and BYMONTHDAY needs FREQ=MONTHLY", ErrInvalidRecurrence, s)
+	}
+	slices.Sort(r.Weekdays)
+	r.Weekdays = slices.Compact(r.Weekdays)
+	return r, nil
+}
+
+func parseRRule(rule string) (Recurrence, error) {
+	var r Recurrence
+	for part := range strings.SplitSeq(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return r, fmt.Errorf("malformed part %q", part)
+		}
+
+		var err error
+		switch key {
+		case "FREQ":
+			switch value {
+			case FreqDaily, FreqWeekly, FreqMonthly:
+				r.Freq = value
+			default:
+				err = fmt.Errorf("unsupported frequency %q", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+		case "BYDAY":
+			r.Weekdays, err = parseWeekdays(strings.Split(value, ","))
+		case "BYMONTHDAY":
+			r.MonthDay, err = strconv.Atoi(value)
+		default:
+			err = fmt.Errorf("unsupported part %q", key)
+		}
+		if err != nil {
+			return r, err
+		}
+	}
+	if r.Freq == "" {
+		return r, errors.New("missing FREQ")
+	}
+	return r, nil
+}
+
+func parseWeekdays(names []string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, name := range names {
+		code := strings.ToUpper(strings.TrimSpace(name))
+		if len(code) > 2 {
+			code = code[:2]
+		}
+		i := slices.Index(weekdayCodes, code)
+		if i < 0 {
+			return nil, fmt.Errorf("unknown weekday %q", name)
+		}
+		days = append(days, time.Weekday(i))
+	}
+	return days, nil
+}
+
+// String formats the rule as an RRULE.
+func (r Recurrence) String() string {
+	rule := "FREQ=" + r.Freq
+	if r.Interval > 1 {
+		rule += ";INTERVAL=" + strconv.Itoa(r.Interval)
+	}
+	if len(r.Weekdays) > 0 {
+		codes := make([]string, len(r.Weekdays))
+		for i, day := range r.Weekdays {
+			codes[i] = weekdayCodes[day]
+		}
+		rule += ";BYDAY=" + strings.Join(codes, ",")
+	}
+	if r.MonthDay > 0 {
+		rule += ";BYMONTHDAY=" + strconv.Itoa(r.MonthDay)
+	}
+	return rule
+}
+
+// Next returns the first occurrence after t, keeping its time of day.
+func (r Recurrence) Next(t time.Time) time.Time {
+	switch r.Freq {
+	case FreqWeekly:
+		if len(r.Weekdays) == 0 {
+			return t.AddDate(0, 0, 7*r.Interval)
+		}
+		start := weekStart(t)
+		for c := t.AddDate(0, 0, 1); ; c = c.AddDate(0, 0, 1) {
+			weeks := int(weekStart(c).Sub(start).Hours()/24+0.5) / 7
+			if weeks%r.Interval == 0 && slices.Contains(r.Weekdays, c.Weekday()) {
+				return c
+			}
+		}
+	case FreqMonthly:
+		day := r.MonthDay
+		if day == 0 {
+			day = t.Day()
+		}
+		first := time.Date(t.Year(), t.Month(), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+		for k := 0; ; k += r.Interval {
+			month := first.AddDate(0, k, 0)
+			last := month.AddDate(0, 1, -1).Day()
+			if next := month.AddDate(0, 0, min(day, last)-1); next.After(t) {
+				return next
+			}
+		}
+	default:
+		return t.AddDate(0, 0, r.Interval)
+	}
+}
+
+// weekStart is the Monday of the week containing t, at t's time of day.
+func weekStart(t time.Time) time.Time {
+	return t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+}
+
+// UpdateRecurrenceByID sets how often an item repeats. An empty rule stops it
+// repeating.
+func UpdateRecurrenceByID(todos []Item, id, rule string) error {
+	var normalized string
+	if rule != "" {
+		r, err := ParseRecurrence(rule)
+		if err != nil {
+			return err
+		}
+		normalized = r.String()
+	}
+
+	i := indexOfID(todos, id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrItemNotFound, id)
+	}
+
+	todos[i].Recurrence = normalized
+	touch(&todos[i])
+	return nil
+}
+
+// Recur appends the next occurrence of a completed recurring item and links
+// the completed one to it, which keeps it as history. The new occurrence is
+// due on the first date of the rule after both the old due date and now; an
+// item without a due date recurs from now. Items that are not completed, do
+// not repeat or have already recurred are left alone.
+func Recur(todos []Item, id string, now time.Time) ([]Item, error) {
+	i := indexOfID(todos, id)
+	if i < 0 {
+		return todos, fmt.Errorf("%w: %s", ErrItemNotFound, id)
+	}
+	done := todos[i]
+	if done.Recurrence == "" || done.Status != Completed || done.NextID != "" {
+		return todos, nil
+	}
+
+	r, err := ParseRecurrence(done.Recurrence)
+	if err != nil {
+		return todos, err
+	}
+
+	due := done.Due
+	if due.IsZero() {
+		y, m, d := now.Date()
+		due = DueDate{Time: time.Date(y, m, d, 0, 0, 0, 0, now.Location())}
+	}
+	for {
+		due.Time = r.Next(due.Time)
+		if due.Deadline().After(now) {
+			break
+		}
+	}
+
+	next := done.Clone()
+	next.ID = NewID()
+	next.Status = NotStarted
+	next.Due = due
+	next.CreatedAt = Now()
+	next.UpdatedAt = next.CreatedAt
+	next.CompletedAt = time.Time{}
+	todos[i].NextID = next.ID
+	touch(&todos[i])
+
+	todos = append(todos, next)
+	syncAncestors(todos, next.ParentID)
+	return todos, nil
+}
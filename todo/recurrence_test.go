@@ -0,0 +1,135 @@
+package todo
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"daily", "Daily", "FREQ=DAILY", false},
+		{"every n days", "every 3 days", "FREQ=DAILY;INTERVAL=3", false},
+		{"weekly on days", "weekly on thu,mon", "FREQ=WEEKLY;BYDAY=MO,TH", false},
+		{"monthly on day", "monthly on 15", "FREQ=MONTHLY;BYMONTHDAY=15", false},
+		{"rrule", "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=FR", "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR", false},
+		{"unknown rule", "fortnightly", "", true},
+		{"unsupported part", "FREQ=DAILY;COUNT=3", "", true},
+		{"byday on monthly", "FREQ=MONTHLY;BYDAY=MO", "", true},
+		{"month day out of range", "monthly on 32", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRecurrence(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got.String())
+			}
+		})
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.Parse(dateLayout, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		rule string
+		from string
+		want string
+	}{
+		{"daily", "2026-10-18", "2026-10-19"},
+		{"every 3 days", "2026-10-30", "2026-11-02"},
+		{"weekly", "2026-10-18", "2026-10-25"},
+		{"weekly on mon,thu", "2026-10-19", "2026-10-22"},
+		{"weekly on mon,thu", "2026-10-22", "2026-10-26"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", "2026-10-19", "2026-11-02"},
+		{"monthly on 15", "2026-10-05", "2026-10-15"},
+		{"monthly on 31", "2026-10-31", "2026-11-30"},
+		{"monthly", "2026-01-31", "2026-02-28"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule+" from "+tt.from, func(t *testing.T) {
+			r, err := ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrence failed: %v", err)
+			}
+			if got := r.Next(date(tt.from)).Format(dateLayout); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRecur(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.Local)
+	due, err := ParseDueDate("2026-10-05")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	todos, err := AddItem(nil, Item{Description: "dependency audit", Due: due, Recurrence: "weekly on mon"})
+	if err != nil {
+		t.Fatalf("AddItem failed: %v", err)
+	}
+	id := todos[0].ID
+
+	if err := UpdateStatusByID(todos, id, Completed); err != nil {
+		t.Fatalf("UpdateStatusByID failed: %v", err)
+	}
+	todos, err = Recur(todos, id, now)
+	if err != nil {
+		t.Fatalf("Recur failed: %v", err)
+	}
+
+	if len(todos) != 2 {
+		t.Fatalf("expected a new occurrence, got %+v", todos)
+	}
+	next := todos[1]
+	if next.Status != NotStarted || next.Due.String() != "2026-10-19" || next.Recurrence != "FREQ=WEEKLY;BYDAY=MO" {
+		t.Errorf("unexpected next occurrence: %+v", next)
+	}
+	if todos[0].NextID != next.ID || todos[0].Status != Completed {
+		t.Errorf("expected the completed occurrence to be kept and linked, got %+v", todos[0])
+	}
+
+	if found, err := FindByDesc(todos, "", "dependency audit"); err != nil || found.ID != next.ID {
+		t.Errorf("expected lookups to find the open occurrence, got %+v, %v", found, err)
+	}
+
+	again, err := Recur(todos, id, now)
+	if err != nil || len(again) != 2 {
+		t.Errorf("expected an occurrence to recur only once, got %d items, %v", len(again), err)
+	}
+}
+
+func TestUpdateRecurrenceByID(t *testing.T) {
+	todos := []Item{{ID: "1", Description: "rotate on-call"}}
+
+	if err := UpdateRecurrenceByID(todos, "1", "every 14 days"); err != nil {
+		t.Fatalf("UpdateRecurrenceByID failed: %v", err)
+	}
+	if todos[0].Recurrence != "FREQ=DAILY;INTERVAL=14" {
+		t.Errorf("unexpected recurrence %q", todos[0].Recurrence)
+	}
+	if err := UpdateRecurrenceByID(todos, "1", "hourly"); !errors.Is(err, ErrInvalidRecurrence) {
+		t.Errorf("expected %v, got %v", ErrInvalidRecurrence, err)
+	}
+	if err := UpdateRecurrenceByID(todos, "1", ""); err != nil || todos[0].Recurrence != "" {
+		t.Errorf("expected the recurrence to be cleared, got %q, %v", todos[0].Recurrence, err)
+	}
+}
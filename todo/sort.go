@@ -0,0 +1,40 @@
+package todo
+
+import (
+	"cmp"
+	"slices"
+)
+
+// SortByPriority orders todos from most to least urgent. Items of equal
+// priority are ordered by deadline, earliest first, with undated items last.
+// The sort is stable, so otherwise the original order is kept.
+func SortByPriority(todos []Item) {
+	slices.SortStableFunc(todos, ComparePriority)
+}
+
+// ComparePriority orders a before b when it is more urgent, as
+// SortByPriority does.
+func ComparePriority(a, b Item) int {
+	if c := cmp.Compare(PriorityRank(b.Priority), PriorityRank(a.Priority)); c != 0 {
+		return c
+	}
+	return compareDue(a.Due, b.Due)
+}
+
+// CompareDue orders a before b when it is due earlier. Undated items come
+// after dated ones.
+func CompareDue(a, b Item) int {
+	return compareDue(a.Due, b.Due)
+}
+
+func compareDue(a, b DueDate) int {
+	switch {
+	case a.IsZero() && b.IsZero():
+		return 0
+	case a.IsZero():
+		return 1
+	case b.IsZero():
+		return -1
+	}
+	return a.Deadline().Compare(b.Deadline())
+}
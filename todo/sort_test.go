@@ -0,0 +1,30 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSortByPriority(t *testing.T) {
+	day := func(d int) DueDate {
+		return DueDate{Time: time.Date(2026, 11, d, 0, 0, 0, 0, time.Local)}
+	}
+	todos := []Item{
+		{Description: "low", Priority: PriorityLow},
+		{Description: "high later", Priority: PriorityHigh, Due: day(5)},
+		{Description: "legacy"},
+		{Description: "high undated", Priority: PriorityHigh},
+		{Description: "urgent", Priority: PriorityUrgent},
+		{Description: "high sooner", Priority: PriorityHigh, Due: day(2)},
+		{Description: "medium", Priority: PriorityMedium},
+	}
+
+	SortByPriority(todos)
+
+	want := []string{"urgent", "high sooner", "high later", "high undated", "legacy", "medium", "low"}
+	for i, desc := range want {
+		if todos[i].Description != desc {
+			t.Errorf("position %d: expected %q, got %q", i, desc, todos[i].Description)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var ErrHasSubtasks = errors.New("status is derived from subtasks")
+
+// Node is an item together with its subtasks, for rendering the hierarchy.
+type Node struct {
+	Item
+	Subtasks []Node
+}
+
+// Tree arranges todos by ParentID, keeping their order. Items whose parent is
+// not in todos, for example because of a filter, are placed at the top level.
+func Tree(todos []Item) []Node {
+	present := make(map[string]bool, len(todos))
+	for _, item := range todos {
+		present[item.ID] = true
+	}
+
+	children := make(map[string][]Item)
+	var roots []Item
+	for _, item := range todos {
+		if item.ParentID == "" || !present[item.ParentID] || item.ParentID == item.ID {
+			roots = append(roots, item)
+			continue
+		}
+		children[item.ParentID] = append(children[item.ParentID], item)
+	}
+
+	visited := make(map[string]bool, len(todos))
+	var build func(items []Item) []Node
+	build = func(items []Item) []Node {
+		var nodes []Node
+		for _, item := range items {
+			if visited[item.ID] {
+				continue
+			}
+			visited[item.ID] = true
+			nodes = append(nodes, Node{Item: item, Subtasks: build(children[item.ID])})
+		}
+		return nodes
+	}
+	return build(roots)
+}
+
+func HasSubtasks(todos []Item, id string) bool {
+	if id == "" {
+		return false
+	}
+	return slices.ContainsFunc(todos, func(item Item) bool {
+		return item.ParentID == id && !IsTrashed(item)
+	})
+}
+
+// Subtasks returns the direct subtasks of an item that are not in the trash.
+func Subtasks(todos []Item, id string) []Item {
+	if id == "" {
+		return nil
+	}
+	var result []Item
+	for _, item := range todos {
+		if item.ParentID == id && !IsTrashed(item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// DerivedStatus is completed when every subtask is completed, not started
+// when none has been started and started otherwise.
+func DerivedStatus(subtasks []Item) string {
+	completed, notStarted := 0, 0
+	for _, item := range subtasks {
+		switch item.Status {
+		case Completed:
+			completed++
+		case NotStarted:
+			notStarted++
+		}
+	}
+
+	switch {
+	case completed == len(subtasks):
+		return Completed
+	case notStarted == len(subtasks):
+		return NotStarted
+	default:
+		return Started
+	}
+}
+
+// syncAncestors recomputes the status of id and of every item above it from
+// their subtasks. It stops at the first item that is blocked from taking its
+// derived status, which keeps the status it had, and returns the BlockedError.
+func syncAncestors(todos []Item, id string) error {
+	for range todos {
+		i := indexOfID(todos, id)
+		if i < 0 {
+			return nil
+		}
+		if subtasks := Subtasks(todos, id); len(subtasks) > 0 {
+			status := DerivedStatus(subtasks)
+			if status != todos[i].Status {
+				if err := checkBlocked(todos, todos[i], status); err != nil {
+					return err
+				}
+			}
+			changeStatus(&todos[i], status)
+		}
+		id = todos[i].ParentID
+	}
+	return nil
+}
+
+// syncDependents recomputes the status of the parents that depend on id, as
+// completing id may have unblocked them.
+func syncDependents(todos []Item, id string) {
+	for _, item := range todos {
+		if slices.Contains(item.DependsOn, id) && HasSubtasks(todos, item.ID) {
+			syncAncestors(todos, item.ID)
+		}
+	}
+}
+
+// subtree returns the ID of an item and of all items below it.
+func subtree(todos []Item, id string) map[string]bool {
+	ids := map[string]bool{id: true}
+	for grew := id != ""; grew; {
+		grew = false
+		for _, item := range todos {
+			if !ids[item.ID] && ids[item.ParentID] {
+				ids[item.ID] = true
+				grew = true
+			}
+		}
+	}
+	return ids
+}
+
+// removeTree removes the item at i together with its subtasks.
+func removeTree(todos []Item, i int) []Item {
+	parentID := todos[i].ParentID
+	ids := subtree(todos, todos[i].ID)
+	remaining := make([]Item, 0, len(todos)-len(ids))
+	for j, item := range todos {
+		if j == i || (item.ID != "" && ids[item.ID]) {
+			continue
+		}
+		remaining = append(remaining, item)
+	}
+	dropDependencies(remaining, ids)
+	syncAncestors(remaining, parentID)
+	return remaining
+}
+
+func setStatus(todos []Item, i int, status string) error {
+	if HasSubtasks(todos, todos[i].ID) {
+		return fmt.Errorf("%w: %s", ErrHasSubtasks, todos[i].Description)
+	}
+	if err := checkBlocked(todos, todos[i], status); err != nil {
+		return err
+	}
+
+	// A subtask cannot complete its parent while the parent is blocked, so
+	// the change is tried on a copy first.
+	next := slices.Clone(todos)
+	changeStatus(&next[i], status)
+	if err := syncAncestors(next, next[i].ParentID); err != nil {
+		return err
+	}
+	copy(todos, next)
+	if status == Completed {
+		syncDependents(todos, todos[i].ID)
+	}
+	return nil
+}
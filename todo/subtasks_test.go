@@ -0,0 +1,184 @@
+package todo
+
+import (
+	"errors"
+	"testing"
+)
+
+func newRelease(t *testing.T) []Item {
+	t.Helper()
+
+	todos, err := AddNewItem(nil, "release 2.3")
+	if err != nil {
+		t.Fatalf("AddNewItem failed: %v", err)
+	}
+	parentID := todos[0].ID
+	for _, desc := range []string{"tag repo", "write changelog"} {
+		todos, err = AddItem(todos, Item{Description: desc, ParentID: parentID})
+		if err != nil {
+			t.Fatalf("AddItem failed: %v", err)
+		}
+	}
+	return todos
+}
+
+func TestParentStatusFollowsSubtasks(t *testing.T) {
+	todos := newRelease(t)
+	parent, tagRepo, changelog := todos[0].ID, todos[1].ID, todos[2].ID
+
+	steps := []struct {
+		id     string
+		status string
+		want   string
+	}{
+		{tagRepo, Started, Started},
+		{tagRepo, Completed, Started},
+		{changelog, Completed, Completed},
+		{changelog, NotStarted, Started},
+		{tagRepo, NotStarted, NotStarted},
+	}
+
+	for _, step := range steps {
+		if err := UpdateStatusByID(todos, step.id, step.status); err != nil {
+			t.Fatalf("UpdateStatusByID failed: %v", err)
+		}
+		if todos[0].Status != step.want {
+			t.Errorf("after setting %s to %s expected parent %q, got %q", step.id, step.status, step.want, todos[0].Status)
+		}
+	}
+
+	if err := UpdateStatusByID(todos, parent, Completed); !errors.Is(err, ErrHasSubtasks) {
+		t.Errorf("expected %v, got %v", ErrHasSubtasks, err)
+	}
+}
+
+func TestNestedSubtasks(t *testing.T) {
+	todos := newRelease(t)
+	tagRepo := todos[1].ID
+
+	todos, err := AddItem(todos, Item{Description: "sign tag", ParentID: tagRepo})
+	if err != nil {
+		t.Fatalf("AddItem failed: %v", err)
+	}
+	if err := UpdateStatusByID(todos, todos[3].ID, Completed); err != nil {
+		t.Fatalf("UpdateStatusByID failed: %v", err)
+	}
+	if err := UpdateStatusByID(todos, todos[2].ID, Completed); err != nil {
+		t.Fatalf("UpdateStatusByID failed: %v", err)
+	}
+	if todos[1].Status != Completed || todos[0].Status != Completed {
+		t.Errorf("expected completion to reach the top, got %q and %q", todos[1].Status, todos[0].Status)
+	}
+
+	tree := Tree(todos)
+	if len(tree) != 1 || len(tree[0].Subtasks) != 2 || len(tree[0].Subtasks[0].Subtasks) != 1 {
+		t.Fatalf("unexpected tree: %+v", tree)
+	}
+
+	todos, err = AddItem(todos, Item{Description: "announce", ParentID: todos[0].ID})
+	if err != nil {
+		t.Fatalf("AddItem failed: %v", err)
+	}
+	if todos[0].Status != Started {
+		t.Errorf("expected a new subtask to reopen the parent, got %q", todos[0].Status)
+	}
+}
+
+func TestAddSubtaskToMissingParent(t *testing.T) {
+	_, err := AddItem(nil, Item{Description: "orphan", ParentID: "missing"})
+	if !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("expected %v, got %v", ErrItemNotFound, err)
+	}
+}
+
+func TestRemoveParentRemovesSubtasks(t *testing.T) {
+	todos := newRelease(t)
+	todos, err := AddNewItem(todos, "unrelated")
+	if err != nil {
+		t.Fatalf("AddNewItem failed: %v", err)
+	}
+
+	todos, err = RemoveItemByID(todos, todos[0].ID)
+	if err != nil {
+		t.Fatalf("RemoveItemByID failed: %v", err)
+	}
+	if len(todos) != 1 || todos[0].Description != "unrelated" {
+		t.Errorf("expected only the unrelated item to remain, got %+v", todos)
+	}
+}
+
+func TestMoveParentMovesSubtasks(t *testing.T) {
+	todos := newRelease(t)
+
+	if err := MoveToListByID(todos, todos[0].ID, "work"); err != nil {
+		t.Fatalf("MoveToListByID failed: %v", err)
+	}
+	for _, item := range todos {
+		if item.List != "work" {
+			t.Errorf("expected %q to move to work, got %q", item.Description, item.List)
+		}
+	}
+}
+
+func TestBlockedParentKeepsStatus(t *testing.T) {
+	todos := newRelease(t)
+	todos, err := AddNewItem(todos, "freeze branch")
+	if err != nil {
+		t.Fatalf("AddNewItem failed: %v", err)
+	}
+	parent, tagRepo, changelog, freeze := todos[0].ID, todos[1].ID, todos[2].ID, todos[3].ID
+	if err := AddDependencyByID(todos, parent, freeze); err != nil {
+		t.Fatalf("AddDependencyByID failed: %v", err)
+	}
+
+	if err := UpdateStatusByID(todos, tagRepo, Completed); err != nil {
+		t.Fatalf("UpdateStatusByID failed: %v", err)
+	}
+	err = UpdateStatusByID(todos, changelog, Completed)
+	var blocked *BlockedError
+	if !errors.As(err, &blocked) || blocked.Description != "release 2.3" {
+		t.Fatalf("expected the parent to be blocked, got %v", err)
+	}
+	if todos[2].Status != NotStarted || todos[0].Status != Started {
+		t.Errorf("expected no change, got subtask %q and parent %q", todos[2].Status, todos[0].Status)
+	}
+
+	if err := UpdateStatusByID(todos, freeze, Completed); err != nil {
+		t.Fatalf("UpdateStatusByID failed: %v", err)
+	}
+	if err := UpdateStatusByID(todos, changelog, Completed); err != nil {
+		t.Fatalf("UpdateStatusByID failed: %v", err)
+	}
+	if todos[0].Status != Completed {
+		t.Errorf("expected the parent to complete, got %q", todos[0].Status)
+	}
+}
+
+func TestTrashingLastSubtaskOfBlockedParent(t *testing.T) {
+	todos := newRelease(t)
+	todos, err := AddNewItem(todos, "freeze branch")
+	if err != nil {
+		t.Fatalf("AddNewItem failed: %v", err)
+	}
+	parent, tagRepo, changelog, freeze := todos[0].ID, todos[1].ID, todos[2].ID, todos[3].ID
+	if err := AddDependencyByID(todos, parent, freeze); err != nil {
+		t.Fatalf("AddDependencyByID failed: %v", err)
+	}
+	if err := UpdateStatusByID(todos, tagRepo, Completed); err != nil {
+		t.Fatalf("UpdateStatusByID failed: %v", err)
+	}
+
+	if err := TrashByID(todos, changelog); err != nil {
+		t.Fatalf("TrashByID failed: %v", err)
+	}
+	if todos[0].Status != Started {
+		t.Errorf("expected the blocked parent to keep its status, got %q", todos[0].Status)
+	}
+
+	if err := UpdateStatusByID(todos, freeze, Completed); err != nil {
+		t.Fatalf("UpdateStatusByID failed: %v", err)
+	}
+	if todos[0].Status != Completed {
+		t.Errorf("expected the parent to complete once unblocked, got %q", todos[0].Status)
+	}
+}
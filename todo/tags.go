@@ -0,0 +1,114 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrInvalidTag = errors.New("invalid tag")
+
+// NormalizeTag lowercases a tag and drops surrounding space and a leading
+// '#', so "#Backend" and "backend" are the same tag.
+func NormalizeTag(tag string) (string, error) {
+	normalized := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	if normalized == "" || strings.ContainsAny(normalized, " \t\n,#") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+	}
+	return normalized, nil
+}
+
+// NormalizeTags normalises every tag and returns them sorted without
+// duplicates.
+func NormalizeTags(tags []string) ([]string, error) {
+	var normalized []string
+	for _, tag := range tags {
+		t, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, t)
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
+
+func HasTag(item Item, tag string) bool {
+	normalized, err := NormalizeTag(tag)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(item.Tags, normalized)
+}
+
+func FilterByTag(todos []Item, tag string) []Item {
+	var result []Item
+	for _, item := range todos {
+		if HasTag(item, tag) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func AddTagsByID(todos []Item, id string, tags ...string) error {
+	normalized, err := NormalizeTags(tags)
+	if err != nil {
+		return err
+	}
+
+	i := indexOfID(todos, id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrItemNotFound, id)
+	}
+
+	merged := slices.Concat(todos[i].Tags, normalized)
+	slices.Sort(merged)
+	todos[i].Tags = slices.Compact(merged)
+	touch(&todos[i])
+	return nil
+}
+
+func RemoveTagsByID(todos []Item, id string, tags ...string) error {
+	normalized, err := NormalizeTags(tags)
+	if err != nil {
+		return err
+	}
+
+	i := indexOfID(todos, id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrItemNotFound, id)
+	}
+
+	todos[i].Tags = slices.DeleteFunc(slices.Clone(todos[i].Tags), func(tag string) bool {
+		return slices.Contains(normalized, tag)
+	})
+	if len(todos[i].Tags) == 0 {
+		todos[i].Tags = nil
+	}
+	touch(&todos[i])
+	return nil
+}
+
+// UpdateTagsByID replaces the tags of an item with a comma-separated list.
+// An empty list removes all tags.
+func UpdateTagsByID(todos []Item, id, tags string) error {
+	var normalized []string
+	if strings.TrimSpace(tags) != "" {
+		var err error
+		normalized, err = NormalizeTags(strings.Split(tags, ","))
+		if err != nil {
+			return err
+		}
+	}
+
+	i := indexOfID(todos, id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrItemNotFound, id)
+	}
+
+	todos[i].Tags = normalized
+	touch(&todos[i])
+	return nil
+}
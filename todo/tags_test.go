@@ -0,0 +1,71 @@
+package todo
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"plain", "backend", "backend", false},
+		{"hash and case", "#Release-2.3", "release-2.3", false},
+		{"surrounding space", "  #ops ", "ops", false},
+		{"empty", "#", "", true},
+		{"inner space", "two words", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeTag(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAddAndRemoveTags(t *testing.T) {
+	todos := []Item{{ID: "1", Description: "test", Tags: []string{"backend"}}}
+
+	if err := AddTagsByID(todos, "1", "#Release-2.3", "backend"); err != nil {
+		t.Fatalf("AddTagsByID failed: %v", err)
+	}
+	if want := []string{"backend", "release-2.3"}; !slices.Equal(todos[0].Tags, want) {
+		t.Errorf("expected tags %v, got %v", want, todos[0].Tags)
+	}
+
+	if err := RemoveTagsByID(todos, "1", "BACKEND"); err != nil {
+		t.Fatalf("RemoveTagsByID failed: %v", err)
+	}
+	if want := []string{"release-2.3"}; !slices.Equal(todos[0].Tags, want) {
+		t.Errorf("expected tags %v, got %v", want, todos[0].Tags)
+	}
+
+	if err := AddTagsByID(todos, "2", "backend"); err == nil {
+		t.Errorf("expected absent item to fail")
+	}
+	if err := AddTagsByID(todos, "1", ""); err == nil {
+		t.Errorf("expected empty tag to fail")
+	}
+}
+
+func TestFilterByTag(t *testing.T) {
+	todos := []Item{
+		{Description: "test1", Tags: []string{"backend", "ops"}},
+		{Description: "test2", Tags: []string{"frontend"}},
+		{Description: "test3"},
+	}
+
+	got := FilterByTag(todos, "#Backend")
+	if len(got) != 1 || got[0].Description != "test1" {
+		t.Errorf("expected only test1, got %+v", got)
+	}
+}
@@ -1,17 +1,49 @@
 package todo
 
-import "strings"
+import (
+	"slices"
+	"strings"
+	"time"
+)
 
 type Item struct {
-    Description string
-    Status      string
+	ID          string
+	Description string
+	Notes       string `json:",omitempty"`
+	Status      string
+	Due         DueDate   `json:",omitzero"`
+	Priority    string    `json:",omitempty"`
+	Tags        []string  `json:",omitempty"`
+	List        string    `json:",omitempty"`
+	ParentID    string    `json:",omitempty"`
+	DependsOn   []string  `json:",omitempty"`
+	Recurrence  string    `json:",omitempty"`
+	NextID      string    `json:",omitempty"`
+	CreatedAt   time.Time `json:",omitzero"`
+	UpdatedAt   time.Time `json:",omitzero"`
+	CompletedAt time.Time `json:",omitzero"`
+	DeletedAt   time.Time `json:",omitzero"`
+	ArchivedAt  time.Time `json:",omitzero"`
+}
+
+// Clone returns a copy of the item that shares no memory with it.
+func (i Item) Clone() Item {
+	i.Tags = slices.Clone(i.Tags)
+	i.DependsOn = slices.Clone(i.DependsOn)
+	return i
 }
 
 type UpdateField string
 
 const (
-    UpdateFieldDescription UpdateField = "description"
-    UpdateFieldStatus      UpdateField = "status"
+	UpdateFieldDescription UpdateField = "description"
+	UpdateFieldStatus      UpdateField = "status"
+	UpdateFieldDue         UpdateField = "due"
+	UpdateFieldPriority    UpdateField = "priority"
+	UpdateFieldTags        UpdateField = "tags"
+	UpdateFieldList        UpdateField = "list"
+	UpdateFieldRecurrence  UpdateField = "recurrence"
+	UpdateFieldNotes       UpdateField = "notes"
 )
 
 const (
@@ -26,4 +58,34 @@ func IsValidStatus(s string) bool {
 		return true
 	}
 	return false
-}
\ No newline at end of file
+}
+
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+func IsValidPriority(p string) bool {
+	switch strings.ToLower(p) {
+	case PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent:
+		return true
+	}
+	return false
+}
+
+// PriorityRank orders priorities from low to urgent. Items saved before
+// priorities existed rank as medium.
+func PriorityRank(p string) int {
+	switch p {
+	case PriorityLow:
+		return 1
+	case PriorityHigh:
+		return 3
+	case PriorityUrgent:
+		return 4
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,165 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrNotInTrash = errors.New("item is not in the trash")
+
+// IsTrashed reports whether an item has been deleted but not purged yet.
+func IsTrashed(item Item) bool {
+	return !item.DeletedAt.IsZero()
+}
+
+// Live returns the items that are not in the trash.
+func Live(todos []Item) []Item {
+	return slices.DeleteFunc(slices.Clone(todos), IsTrashed)
+}
+
+// Trash returns the items in the trash, most recently deleted first.
+func Trash(todos []Item) []Item {
+	var result []Item
+	for _, item := range todos {
+		if IsTrashed(item) {
+			result = append(result, item)
+		}
+	}
+	slices.SortStableFunc(result, func(a, b Item) int {
+		return b.DeletedAt.Compare(a.DeletedAt)
+	})
+	return result
+}
+
+// FindTrashedByDesc returns the most recently deleted item with desc in list.
+func FindTrashedByDesc(todos []Item, list, desc string) (Item, error) {
+	normalized, err := NormalizeList(list)
+	if err != nil {
+		return Item{}, err
+	}
+	for _, item := range Trash(todos) {
+		if item.List == normalized && item.Description == strings.ToLower(desc) {
+			return item, nil
+		}
+	}
+	return Item{}, fmt.Errorf("%w: %s", ErrItemNotFound, desc)
+}
+
+// TrashByID moves an item and its subtasks to the trash. They keep their
+// parent and dependencies so that RestoreByID can put them back as they were.
+func TrashByID(todos []Item, id string) error {
+	i := indexOfID(todos, id)
+	if i < 0 || IsTrashed(todos[i]) {
+		return fmt.Errorf("%w: %s", ErrItemNotFound, id)
+	}
+
+	now := Now()
+	ids := subtree(todos, id)
+	for j := range todos {
+		if ids[todos[j].ID] && !IsTrashed(todos[j]) {
+			todos[j].DeletedAt = now
+			touch(&todos[j])
+		}
+	}
+	syncAncestors(todos, todos[i].ParentID)
+	return nil
+}
+
+// TrashList moves every item of a list to the trash.
+func TrashList(todos []Item, name string) error {
+	list, err := NormalizeList(name)
+	if err != nil {
+		return err
+	}
+
+	now := Now()
+	found := false
+	for i := range todos {
+		if todos[i].List == list && !IsTrashed(todos[i]) {
+			todos[i].DeletedAt = now
+			touch(&todos[i])
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", ErrListNotFound, displayList(list))
+	}
+	return nil
+}
+
+// RestoreByID takes an item out of the trash together with the subtasks that
+// were deleted with it. Deleted ancestors come back as well so the item keeps
+// its place. Nothing is restored if a description is taken in the meantime.
+func RestoreByID(todos []Item, id string) error {
+	i := indexOfID(todos, id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrItemNotFound, id)
+	}
+	if !IsTrashed(todos[i]) {
+		return fmt.Errorf("%w: %s", ErrNotInTrash, todos[i].Description)
+	}
+
+	deletedAt := todos[i].DeletedAt
+	below := subtree(todos, id)
+	var restore []int
+	for j, item := range todos {
+		if below[item.ID] && item.DeletedAt.Equal(deletedAt) {
+			restore = append(restore, j)
+		}
+	}
+	parentID := todos[i].ParentID
+	for range todos {
+		p := indexOfID(todos, parentID)
+		if p < 0 {
+			break
+		}
+		if IsTrashed(todos[p]) {
+			restore = append(restore, p)
+		}
+		parentID = todos[p].ParentID
+	}
+
+	for _, j := range restore {
+		if indexOfDesc(todos, todos[j].List, todos[j].Description) >= 0 {
+			return fmt.Errorf("%w: %s", ErrItemExists, todos[j].Description)
+		}
+	}
+	for _, j := range restore {
+		todos[j].DeletedAt = time.Time{}
+		touch(&todos[j])
+	}
+	syncAncestors(todos, todos[i].ParentID)
+	return nil
+}
+
+// PurgeByID permanently removes an item in the trash and its subtasks.
+func PurgeByID(todos []Item, id string) ([]Item, error) {
+	i := indexOfID(todos, id)
+	if i < 0 {
+		return todos, fmt.Errorf("%w: %s", ErrItemNotFound, id)
+	}
+	if !IsTrashed(todos[i]) {
+		return todos, fmt.Errorf("%w: %s", ErrNotInTrash, todos[i].Description)
+	}
+
+	return removeTree(todos, i), nil
+}
+
+// PurgeTrash permanently removes the items deleted at or before cutoff and
+// returns how many there were.
+func PurgeTrash(todos []Item, cutoff time.Time) ([]Item, int) {
+	removed := map[string]bool{}
+	remaining := make([]Item, 0, len(todos))
+	for _, item := range todos {
+		if IsTrashed(item) && !item.DeletedAt.After(cutoff) {
+			removed[item.ID] = true
+			continue
+		}
+		remaining = append(remaining, item)
+	}
+	dropDependencies(remaining, removed)
+	return remaining, len(removed)
+}
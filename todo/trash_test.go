@@ -0,0 +1,98 @@
+package todo
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTrashAndRestoreSubtasks(t *testing.T) {
+	todos := newRelease(t)
+	parent, tagRepo := todos[0].ID, todos[1].ID
+
+	if err := TrashByID(todos, parent); err != nil {
+		t.Fatalf("TrashByID failed: %v", err)
+	}
+	if live := Live(todos); len(live) != 0 {
+		t.Fatalf("expected the whole release in the trash, got %+v", live)
+	}
+
+	if err := RestoreByID(todos, tagRepo); err != nil {
+		t.Fatalf("RestoreByID failed: %v", err)
+	}
+	live := Live(todos)
+	if len(live) != 2 || live[0].ID != parent || live[1].ID != tagRepo {
+		t.Fatalf("expected the subtask and its parent to come back, got %+v", live)
+	}
+
+	if err := TrashByID(todos, tagRepo); err != nil {
+		t.Fatalf("TrashByID failed: %v", err)
+	}
+	if err := RestoreByID(todos, parent); !errors.Is(err, ErrNotInTrash) {
+		t.Errorf("expected %v, got %v", ErrNotInTrash, err)
+	}
+	if HasSubtasks(todos, parent) {
+		t.Errorf("expected trashed subtasks to be ignored, got %+v", Subtasks(todos, parent))
+	}
+}
+
+func TestRestoreRefusesTakenDescription(t *testing.T) {
+	todos, err := AddNewItem(nil, "buy milk")
+	if err != nil {
+		t.Fatalf("AddNewItem failed: %v", err)
+	}
+	trashed := todos[0].ID
+	if err := TrashByID(todos, trashed); err != nil {
+		t.Fatalf("TrashByID failed: %v", err)
+	}
+
+	todos, err = AddNewItem(todos, "buy milk")
+	if err != nil {
+		t.Fatalf("expected a trashed description to be free, got %v", err)
+	}
+	if err := RestoreByID(todos, trashed); !errors.Is(err, ErrItemExists) {
+		t.Errorf("expected %v, got %v", ErrItemExists, err)
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	deleted := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	useClock(t, deleted)
+
+	todos, err := AddNewItem(nil, "old")
+	if err != nil {
+		t.Fatalf("AddNewItem failed: %v", err)
+	}
+	todos, err = AddItem(todos, Item{Description: "waiting", DependsOn: []string{todos[0].ID}})
+	if err != nil {
+		t.Fatalf("AddItem failed: %v", err)
+	}
+	if err := TrashByID(todos, todos[0].ID); err != nil {
+		t.Fatalf("TrashByID failed: %v", err)
+	}
+
+	useClock(t, deleted.Add(48*time.Hour))
+	todos, err = AddNewItem(todos, "recent")
+	if err != nil {
+		t.Fatalf("AddNewItem failed: %v", err)
+	}
+	if err := TrashByID(todos, todos[2].ID); err != nil {
+		t.Fatalf("TrashByID failed: %v", err)
+	}
+
+	todos, purged := PurgeTrash(todos, deleted.Add(24*time.Hour))
+	if purged != 1 || len(todos) != 2 {
+		t.Fatalf("expected only the old item to be purged, got %d and %+v", purged, todos)
+	}
+	if todos[0].DependsOn != nil {
+		t.Errorf("expected the purged item to be dropped from dependencies, got %v", todos[0].DependsOn)
+	}
+
+	if _, err := PurgeByID(todos, todos[0].ID); !errors.Is(err, ErrNotInTrash) {
+		t.Errorf("expected %v, got %v", ErrNotInTrash, err)
+	}
+	todos, err = PurgeByID(todos, todos[1].ID)
+	if err != nil || len(todos) != 1 {
+		t.Errorf("expected the recent item to be purged, got %+v, %v", todos, err)
+	}
+}
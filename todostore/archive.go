@@ -0,0 +1,28 @@
+package todostore
+
+import (
+	"context"
+	"time"
+
+	"todo-app/storage"
+	"todo-app/todo"
+)
+
+// ArchiveCompleted archives the items completed at or before cutoff and
+// returns how many there were. Nothing is written when there are none.
+func ArchiveCompleted(ctx context.Context, cutoff time.Time, store storage.Store) (int, error) {
+	todos, err := load(ctx, store)
+	if err != nil {
+		return 0, err
+	}
+	if todo.ArchiveCompleted(todos, cutoff) == 0 {
+		return 0, nil
+	}
+
+	var archived int
+	err = update(ctx, store, func(todos []todo.Item) ([]todo.Item, error) {
+		archived = todo.ArchiveCompleted(todos, cutoff)
+		return todos, nil
+	})
+	return archived, err
+}
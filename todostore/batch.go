@@ -0,0 +1,176 @@
+package todostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"todo-app/storage"
+	"todo-app/todo"
+)
+
+var ErrInvalidBatchOp = errors.New("invalid batch operation")
+
+// BatchOp is the kind of change an Operation makes.
+type BatchOp string
+
+const (
+	BatchCreate BatchOp = "create"
+	BatchUpdate BatchOp = "update"
+	BatchDelete BatchOp = "delete"
+)
+
+// Operation is one change of a batch. Create adds Item, to List when Item
+// names no list; update sets Field to NewValue and delete moves the item to
+// the trash. Both find their item by ID or, without one, by Description in
+// List.
+type Operation struct {
+	Op          BatchOp
+	ID          string           `json:",omitempty"`
+	List        string           `json:",omitempty"`
+	Description string           `json:",omitempty"`
+	Item        todo.Item        `json:",omitzero"`
+	Field       todo.UpdateField `json:",omitempty"`
+	NewValue    string           `json:",omitempty"`
+}
+
+// Result statuses.
+const (
+	StatusApplied = "applied"
+	StatusFailed  = "failed"
+	StatusAborted = "aborted"
+)
+
+// Result is the outcome of one operation of a batch. An applied operation
+// holds the item as it left it. When a batch fails, the operation at fault
+// holds its error and every other one is aborted.
+type Result struct {
+	Op     BatchOp
+	Status string
+	Item   todo.Item `json:",omitzero"`
+	Error  string    `json:",omitempty"`
+}
+
+// BatchError reports the operation that made a batch fail. It unwraps to
+// that operation's error.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// Batch applies ops in order as a single update, so either all of them are
+// stored or none is. It stops at the first operation that fails and returns
+// a BatchError for it.
+func Batch(ctx context.Context, ops []Operation, store storage.Store) ([]Result, error) {
+	results := make([]Result, len(ops))
+	for i, op := range ops {
+		results[i] = Result{Op: op.Op, Status: StatusAborted}
+	}
+	if len(ops) == 0 {
+		return results, nil
+	}
+
+	applied := make([]todo.Item, len(ops))
+	err := update(ctx, store, func(todos []todo.Item) ([]todo.Item, error) {
+		for i, op := range ops {
+			var err error
+			todos, applied[i], err = applyOperation(todos, op)
+			if err != nil {
+				return nil, &BatchError{Index: i, Err: err}
+			}
+		}
+		return todos, nil
+	})
+
+	var failed *BatchError
+	switch {
+	case errors.As(err, &failed):
+		results[failed.Index].Status = StatusFailed
+		results[failed.Index].Error = failed.Err.Error()
+	case err == nil:
+		for i := range results {
+			results[i].Status = StatusApplied
+			results[i].Item = applied[i]
+		}
+	}
+	return results, err
+}
+
+// applyOperation makes the change of op to todos and returns the item it
+// created, changed or trashed.
+func applyOperation(todos []todo.Item, op Operation) ([]todo.Item, todo.Item, error) {
+	if op.Op == BatchCreate {
+		draft, err := op.draft()
+		if err != nil {
+			return nil, todo.Item{}, err
+		}
+		todos, err := todo.AddItem(todos, draft)
+		if err != nil {
+			return nil, todo.Item{}, err
+		}
+		return todos, todos[len(todos)-1].Clone(), nil
+	}
+	if op.Op != BatchUpdate && op.Op != BatchDelete {
+		return nil, todo.Item{}, fmt.Errorf("%w: %s - valid operations are: %s, %s, %s", ErrInvalidBatchOp, op.Op, BatchCreate, BatchUpdate, BatchDelete)
+	}
+
+	id, err := op.target(todos)
+	if err != nil {
+		return nil, todo.Item{}, err
+	}
+	if op.Op == BatchUpdate {
+		todos, err = updateField(todos, id, op.Field, op.NewValue)
+	} else {
+		err = todo.TrashByID(todos, id)
+	}
+	if err != nil {
+		return nil, todo.Item{}, err
+	}
+
+	item, err := todo.FindByID(todos, id)
+	return todos, item.Clone(), err
+}
+
+// draft returns the item a create adds. List and Item.List may both be set
+// only when they name the same list.
+func (op Operation) draft() (todo.Item, error) {
+	draft := op.Item
+	if draft.List == "" {
+		draft.List = op.List
+		return draft, nil
+	}
+	if op.List != "" {
+		list, _ := todo.NormalizeList(op.List)
+		itemList, _ := todo.NormalizeList(draft.List)
+		if list != itemList {
+			return todo.Item{}, fmt.Errorf("%w: create names lists %s and %s", ErrInvalidBatchOp, op.List, draft.List)
+		}
+	}
+	return draft, nil
+}
+
+// target returns the ID of the item op changes. Items trashed by an earlier
+// operation of the batch are gone.
+func (op Operation) target(todos []todo.Item) (string, error) {
+	switch {
+	case op.ID != "":
+		item, err := todo.FindByID(todos, op.ID)
+		if err == nil && todo.IsTrashed(item) {
+			err = fmt.Errorf("%w: %s", todo.ErrItemNotFound, op.ID)
+		}
+		return item.ID, err
+	case op.Description != "":
+		item, err := todo.FindByDesc(todos, op.List, op.Description)
+		return item.ID, err
+	default:
+		return "", fmt.Errorf("%w: %s needs an ID or a description", ErrInvalidBatchOp, op.Op)
+	}
+}
@@ -0,0 +1,142 @@
+package todostore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"todo-app/storage"
+	"todo-app/todo"
+)
+
+func TestBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		ops      []Operation
+		statuses []string
+		wantErr  error
+		remain   int
+	}{
+		{
+			name: "all applied",
+			ops: []Operation{
+				{Op: BatchCreate, Item: todo.Item{Description: "book flights"}},
+				{Op: BatchUpdate, ID: "1", Field: todo.UpdateFieldStatus, NewValue: todo.Completed},
+				{Op: BatchDelete, Description: "file taxes"},
+			},
+			statuses: []string{StatusApplied, StatusApplied, StatusApplied},
+			remain:   2,
+		},
+		{
+			name: "one fails",
+			ops: []Operation{
+				{Op: BatchCreate, Item: todo.Item{Description: "book flights"}},
+				{Op: BatchUpdate, Description: "walk dog", Field: todo.UpdateFieldStatus, NewValue: todo.Completed},
+				{Op: BatchDelete, ID: "1"},
+			},
+			statuses: []string{StatusAborted, StatusFailed, StatusAborted},
+			wantErr:  todo.ErrItemNotFound,
+			remain:   2,
+		},
+		{
+			name: "deleted earlier in the batch",
+			ops: []Operation{
+				{Op: BatchDelete, ID: "1"},
+				{Op: BatchUpdate, ID: "1", Field: todo.UpdateFieldPriority, NewValue: todo.PriorityHigh},
+			},
+			statuses: []string{StatusAborted, StatusFailed},
+			wantErr:  todo.ErrItemNotFound,
+			remain:   2,
+		},
+		{
+			name:     "unknown operation",
+			ops:      []Operation{{Op: "rename", ID: "1"}},
+			statuses: []string{StatusFailed},
+			wantErr:  ErrInvalidBatchOp,
+			remain:   2,
+		},
+		{
+			name: "create in a list",
+			ops: []Operation{
+				{Op: BatchCreate, List: "work", Item: todo.Item{Description: "file taxes"}},
+				{Op: BatchDelete, List: "work", Description: "file taxes"},
+			},
+			statuses: []string{StatusApplied, StatusApplied},
+			remain:   2,
+		},
+		{
+			name:     "create in two lists",
+			ops:      []Operation{{Op: BatchCreate, List: "work", Item: todo.Item{Description: "book flights", List: "home"}}},
+			statuses: []string{StatusFailed},
+			wantErr:  ErrInvalidBatchOp,
+			remain:   2,
+		},
+		{
+			name:     "no target",
+			ops:      []Operation{{Op: BatchDelete}},
+			statuses: []string{StatusFailed},
+			wantErr:  ErrInvalidBatchOp,
+			remain:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := storage.NewMemoryStore(
+				todo.Item{ID: "1", Description: "write report", Status: todo.NotStarted},
+				todo.Item{ID: "2", Description: "file taxes", Status: todo.NotStarted},
+			)
+
+			results, err := Batch(ctx, tt.ops, store)
+			if tt.wantErr != nil {
+				var failed *BatchError
+				if !errors.Is(err, tt.wantErr) || !errors.As(err, &failed) {
+					t.Fatalf("expected a BatchError for %v, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("Batch failed: %v", err)
+			}
+
+			for i, result := range results {
+				if result.Status != tt.statuses[i] {
+					t.Errorf("operation %d: expected %s, got %+v", i, tt.statuses[i], result)
+				}
+			}
+
+			live, err := load(ctx, store)
+			if err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+			if len(live) != tt.remain {
+				t.Errorf("expected %d items, got %+v", tt.remain, live)
+			}
+			if tt.wantErr != nil && live[0].Status != todo.NotStarted {
+				t.Errorf("expected a failed batch to change nothing, got %+v", live)
+			}
+		})
+	}
+}
+
+func TestBatchIsOneUndo(t *testing.T) {
+	store := storage.NewMemoryStore()
+
+	_, err := Batch(as("alice"), []Operation{
+		{Op: BatchCreate, Item: todo.Item{Description: "write report"}},
+		{Op: BatchCreate, Item: todo.Item{Description: "file taxes"}},
+	}, store)
+	if err != nil {
+		t.Fatalf("Batch failed: %v", err)
+	}
+
+	if done, err := Undo(as("alice"), 1, store); err != nil || done != 1 {
+		t.Fatalf("Undo failed: %d, %v", done, err)
+	}
+	todos, err := store.LoadTodos(context.Background())
+	if err != nil {
+		t.Fatalf("LoadTodos failed: %v", err)
+	}
+	if len(todos) != 0 {
+		t.Errorf("expected undo to revert the whole batch, got %+v", todos)
+	}
+}
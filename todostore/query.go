@@ -0,0 +1,255 @@
+package todostore
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"todo-app/storage"
+	"todo-app/todo"
+)
+
+var (
+	ErrInvalidQuery  = errors.New("invalid query")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// SortOrder selects how List orders items: a field, optionally prefixed by
+// "-" to reverse it. Items that compare equal keep their insertion order.
+type SortOrder string
+
+const (
+	SortDefault     SortOrder = ""
+	SortPriority    SortOrder = "priority"
+	SortDue         SortOrder = "due"
+	SortCreated     SortOrder = "created"
+	SortUpdated     SortOrder = "updated"
+	SortCompleted   SortOrder = "completed"
+	SortDescription SortOrder = "description"
+	SortStatus      SortOrder = "status"
+)
+
+var sortOrders = []SortOrder{SortPriority, SortDue, SortCreated, SortUpdated, SortCompleted, SortDescription, SortStatus}
+
+// compare returns how the order sorts items, or nil for insertion order.
+func (o SortOrder) compare() (func(a, b todo.Item) int, error) {
+	field, descending := strings.CutPrefix(string(o), "-")
+
+	var compare func(a, b todo.Item) int
+	switch SortOrder(field) {
+	case SortDefault:
+		if descending {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidSort, o)
+		}
+		return nil, nil
+	case SortPriority:
+		compare = todo.ComparePriority
+	case SortDue:
+		compare = todo.CompareDue
+	case SortCreated:
+		compare = byTime(func(item todo.Item) time.Time { return item.CreatedAt })
+	case SortUpdated:
+		compare = byTime(func(item todo.Item) time.Time { return item.UpdatedAt })
+	case SortCompleted:
+		compare = byTime(func(item todo.Item) time.Time { return item.CompletedAt })
+	case SortDescription:
+		compare = func(a, b todo.Item) int { return strings.Compare(a.Description, b.Description) }
+	case SortStatus:
+		compare = func(a, b todo.Item) int { return cmp.Compare(statusRank(a.Status), statusRank(b.Status)) }
+	default:
+		valid := make([]string, len(sortOrders))
+		for i, order := range sortOrders {
+			valid[i] = string(order)
+		}
+		return nil, fmt.Errorf("%w: %s - valid orders are: %s, prefixed by - to reverse them", ErrInvalidSort, o, strings.Join(valid, ", "))
+	}
+
+	if descending {
+		return func(a, b todo.Item) int { return compare(b, a) }, nil
+	}
+	return compare, nil
+}
+
+func byTime(field func(todo.Item) time.Time) func(a, b todo.Item) int {
+	return func(a, b todo.Item) int { return field(a).Compare(field(b)) }
+}
+
+// statusRank orders statuses from not started to completed.
+func statusRank(status string) int {
+	switch status {
+	case todo.Started:
+		return 1
+	case todo.Completed:
+		return 2
+	}
+	return 0
+}
+
+// Query selects which items List returns, in what order and which page of
+// them. Empty fields match everything; Tags matches items carrying all of
+// them and a zero Limit returns every item. Cursor continues from the page that returned it and cannot be combined
+// with Offset.
+type Query struct {
+	Status   string
+	Text     string
+	Tags     []string
+	List     string
+	Archived bool
+	Sort     SortOrder
+	Limit    int
+	Offset   int
+	Cursor   string
+}
+
+// Page is the part of the items matching a Query that List returned. Total
+// counts every match, and NextCursor is empty on the last page.
+type Page struct {
+	Items      []todo.Item
+	Total      int
+	NextCursor string
+}
+
+// cursor points just past the last item of a page. When that item no longer
+// matches, the next page starts where it would sort now, or at the offset it
+// had once it has been purged.
+type cursor struct {
+	After  string `json:"after"`
+	Offset int    `json:"offset"`
+}
+
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil || c.Offset < 0 {
+		return cursor{}, fmt.Errorf("%w: %s", ErrInvalidCursor, s)
+	}
+	return c, nil
+}
+
+// resume returns the index in the sorted todos of the first item after the
+// cursor. all holds every stored item, in the order the sort keeps for ties.
+func resume(todos, all []todo.Item, after cursor, compare func(a, b todo.Item) int) int {
+	if i := slices.IndexFunc(todos, func(item todo.Item) bool { return item.ID == after.After }); i >= 0 {
+		return i + 1
+	}
+	last := slices.IndexFunc(all, func(item todo.Item) bool { return item.ID == after.After })
+	if last < 0 {
+		return after.Offset
+	}
+
+	position := make(map[string]int, len(all))
+	for i, item := range all {
+		position[item.ID] = i
+	}
+	i, _ := slices.BinarySearchFunc(todos, all[last], func(item, last todo.Item) int {
+		if compare != nil {
+			if c := compare(item, last); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(position[item.ID], position[last.ID])
+	})
+	return i
+}
+
+func GetAll(ctx context.Context, q Query, store storage.Store) error {
+	page, err := List(ctx, q, store)
+	if err != nil {
+		return err
+	}
+
+	todo.PrintTodos(page.Items)
+	if page.NextCursor != "" {
+		fmt.Printf("Showing %d of %d items. Next page: -cursor %s\n", len(page.Items), page.Total, page.NextCursor)
+	}
+	return nil
+}
+
+// List returns the page of items that q asks for. Archived items are left
+// out unless q.Archived is set.
+func List(ctx context.Context, q Query, store storage.Store) (Page, error) {
+	compare, err := q.Sort.compare()
+	if err != nil {
+		return Page{}, err
+	}
+	if q.Limit < 0 || q.Offset < 0 {
+		return Page{}, fmt.Errorf("%w: limit and offset cannot be negative", ErrInvalidQuery)
+	}
+	if q.Cursor != "" && q.Offset > 0 {
+		return Page{}, fmt.Errorf("%w: use either a cursor or an offset", ErrInvalidQuery)
+	}
+	var after cursor
+	if q.Cursor != "" {
+		if after, err = decodeCursor(q.Cursor); err != nil {
+			return Page{}, err
+		}
+	}
+	status := strings.ToLower(q.Status)
+	if status != "" && !todo.IsValidStatus(status) {
+		return Page{}, fmt.Errorf("%w: %s", todo.ErrInvalidStatus, q.Status)
+	}
+
+	all, err := store.LoadTodos(ctx)
+	if err != nil {
+		return Page{}, err
+	}
+	todos := todo.Live(all)
+
+	if !q.Archived {
+		todos = todo.Unarchived(todos)
+	}
+
+	for _, tag := range q.Tags {
+		normalized, err := todo.NormalizeTag(tag)
+		if err != nil {
+			return Page{}, err
+		}
+		todos = todo.FilterByTag(todos, normalized)
+	}
+
+	if q.List != "" {
+		if _, err := todo.NormalizeList(q.List); err != nil {
+			return Page{}, err
+		}
+		todos = todo.FilterByList(todos, q.List)
+	}
+
+	text := strings.ToLower(q.Text)
+	todos = slices.DeleteFunc(todos, func(item todo.Item) bool {
+		return (status != "" && item.Status != status) || !strings.Contains(item.Description, text)
+	})
+
+	if compare != nil {
+		slices.SortStableFunc(todos, compare)
+	}
+
+	start := q.Offset
+	if q.Cursor != "" {
+		start = resume(todos, all, after, compare)
+	}
+	start = min(start, len(todos))
+	end := len(todos)
+	if q.Limit > 0 {
+		end = min(start+q.Limit, len(todos))
+	}
+
+	page := Page{Items: todos[start:end], Total: len(todos)}
+	if end < len(todos) && end > start {
+		page.NextCursor = cursor{After: todos[end-1].ID, Offset: end}.encode()
+	}
+	return page, nil
+}
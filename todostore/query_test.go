@@ -0,0 +1,134 @@
+package todostore
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"todo-app/storage"
+	"todo-app/todo"
+)
+
+func newQueryStore() storage.Store {
+	day := func(d int) time.Time {
+		return time.Date(2026, 10, d, 9, 0, 0, 0, time.UTC)
+	}
+	return storage.NewMemoryStore(
+		todo.Item{ID: "1", Description: "write report", Status: todo.Started, CreatedAt: day(3), Tags: []string{"work", "urgent"}},
+		todo.Item{ID: "2", Description: "file taxes", Status: todo.NotStarted, CreatedAt: day(1)},
+		todo.Item{ID: "3", Description: "review report", Status: todo.Started, CreatedAt: day(2), Tags: []string{"work"}},
+		todo.Item{ID: "4", Description: "book flights", Status: todo.Completed, CreatedAt: day(4), ArchivedAt: day(5)},
+		todo.Item{ID: "5", Description: "renew passport", Status: todo.Started, CreatedAt: day(5)},
+	)
+}
+
+func TestList(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   Query
+		want    []string
+		total   int
+		more    bool
+		wantErr error
+	}{
+		{"everything", Query{}, []string{"1", "2", "3", "5"}, 4, false, nil},
+		{"archived", Query{Archived: true}, []string{"1", "2", "3", "4", "5"}, 5, false, nil},
+		{"status", Query{Status: "Started"}, []string{"1", "3", "5"}, 3, false, nil},
+		{"text", Query{Text: "REPORT"}, []string{"1", "3"}, 2, false, nil},
+		{"tag", Query{Tags: []string{"#Work"}}, []string{"1", "3"}, 2, false, nil},
+		{"every tag", Query{Tags: []string{"work", "urgent"}}, []string{"1"}, 1, false, nil},
+		{"invalid tag", Query{Tags: []string{"work", "a b"}}, nil, 0, false, todo.ErrInvalidTag},
+		{"sort created", Query{Sort: SortCreated}, []string{"2", "3", "1", "5"}, 4, false, nil},
+		{"sort created descending", Query{Sort: "-created"}, []string{"5", "1", "3", "2"}, 4, false, nil},
+		{"limit", Query{Status: todo.Started, Sort: SortCreated, Limit: 2}, []string{"3", "1"}, 3, true, nil},
+		{"offset", Query{Status: todo.Started, Sort: SortCreated, Limit: 2, Offset: 2}, []string{"5"}, 3, false, nil},
+		{"offset past the end", Query{Offset: 10}, nil, 4, false, nil},
+		{"unknown sort", Query{Sort: "colour"}, nil, 0, false, ErrInvalidSort},
+		{"reversed default", Query{Sort: "-"}, nil, 0, false, ErrInvalidSort},
+		{"unknown status", Query{Status: "blocked"}, nil, 0, false, todo.ErrInvalidStatus},
+		{"negative limit", Query{Limit: -1}, nil, 0, false, ErrInvalidQuery},
+		{"cursor and offset", Query{Cursor: cursor{After: "1"}.encode(), Offset: 1}, nil, 0, false, ErrInvalidQuery},
+		{"bad cursor", Query{Cursor: "not a cursor"}, nil, 0, false, ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := List(context.Background(), tt.query, newQueryStore())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+
+			var got []string
+			for _, item := range page.Items {
+				got = append(got, item.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+			if page.Total != tt.total {
+				t.Errorf("expected a total of %d, got %d", tt.total, page.Total)
+			}
+			if more := page.NextCursor != ""; more != tt.more {
+				t.Errorf("expected a next cursor %v, got %q", tt.more, page.NextCursor)
+			}
+		})
+	}
+}
+
+func TestListCursor(t *testing.T) {
+	ctx := context.Background()
+	store := newQueryStore()
+	q := Query{Sort: SortCreated, Limit: 2}
+
+	first, err := List(ctx, q, store)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+
+	// The next page starts after the last item seen, even when items before
+	// it have gone in the meantime.
+	if err := RemoveByID(ctx, "2", store); err != nil {
+		t.Fatalf("RemoveByID failed: %v", err)
+	}
+	q.Cursor = first.NextCursor
+	second, err := List(ctx, q, store)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(second.Items) != 2 || second.Items[0].ID != "1" || second.Items[1].ID != "5" || second.NextCursor != "" {
+		t.Errorf("expected the last two items, got %+v", second)
+	}
+
+	// When the last item seen has gone too, the page starts where it would
+	// be now.
+	if err := RemoveByID(ctx, "3", store); err != nil {
+		t.Fatalf("RemoveByID failed: %v", err)
+	}
+	second, err = List(ctx, q, store)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(second.Items) != 2 || second.Items[0].ID != "1" {
+		t.Errorf("expected the items after the first page, got %+v", second.Items)
+	}
+
+	// Once it has been purged, the page starts at the offset it had.
+	if err := PurgeByID(ctx, "3", store); err != nil {
+		t.Fatalf("PurgeByID failed: %v", err)
+	}
+	second, err = List(ctx, q, store)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(second.Items) != 0 {
+		t.Errorf("expected nothing after the old offset, got %+v", second.Items)
+	}
+}
@@ -0,0 +1,30 @@
+package todostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"todo-app/storage"
+	"todo-app/todo"
+)
+
+var ErrEmptySearch = errors.New("search needs at least one word")
+
+// Search returns the items with a word in their description or tags that
+// starts with every word of query, most relevant first. Archived items are
+// left out unless archived is set.
+func Search(ctx context.Context, query string, archived bool, store storage.Store) ([]storage.Match, error) {
+	if len(storage.Tokenize(query)) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrEmptySearch, query)
+	}
+
+	matches, err := store.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(matches, func(match storage.Match) bool {
+		return todo.IsTrashed(match.Item) || (!archived && todo.IsArchived(match.Item))
+	}), nil
+}
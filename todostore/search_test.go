@@ -0,0 +1,46 @@
+package todostore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"todo-app/storage"
+	"todo-app/todo"
+)
+
+func TestSearchSkipsTrashAndArchive(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	store := storage.NewMemoryStore(
+		todo.Item{ID: "1", Description: "deploy prod", Status: todo.NotStarted},
+		todo.Item{ID: "2", Description: "deploy staging", Status: todo.NotStarted, DeletedAt: now},
+		todo.Item{ID: "3", Description: "deploy canary", Status: todo.Completed, ArchivedAt: now},
+	)
+
+	tests := []struct {
+		name     string
+		archived bool
+		want     int
+	}{
+		{"live", false, 1},
+		{"with archived", true, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := Search(ctx, "deploy", tt.archived, store)
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if len(matches) != tt.want {
+				t.Errorf("expected %d matches, got %+v", tt.want, matches)
+			}
+		})
+	}
+
+	if _, err := Search(ctx, " - ", false, store); !errors.Is(err, ErrEmptySearch) {
+		t.Errorf("expected %v, got %v", ErrEmptySearch, err)
+	}
+}
@@ -4,81 +4,377 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"todo-app/storage"
 	"todo-app/todo"
 )
 
-var ErrInvalidUpdateField = errors.New("invalid update field")
+var (
+	ErrInvalidUpdateField = errors.New("invalid update field")
+	ErrInvalidSort        = errors.New("invalid sort order")
+)
 
-func GetAll(ctx context.Context, fs *storage.FileStore) error {
-	todos, err := fs.LoadTodos(ctx)
+// load returns the items that are not in the trash.
+func load(ctx context.Context, store storage.Store) ([]todo.Item, error) {
+	todos, err := store.LoadTodos(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return todo.Live(todos), nil
+}
+
+// update runs fn against the items that are not in the trash, which is all
+// that operations other than those on the trash itself get to see. Items
+// already in the trash are kept after the others.
+func update(ctx context.Context, store storage.Store, fn storage.UpdateFunc) error {
+	return store.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+		live, err := fn(todo.Live(todos))
+		if err != nil {
+			return nil, err
+		}
+		return append(live, todo.Trash(todos)...), nil
+	})
+}
+
+func AddTags(ctx context.Context, list, desc string, tags []string, store storage.Store) error {
+	return update(ctx, store, func(todos []todo.Item) ([]todo.Item, error) {
+		item, err := todo.FindByDesc(todos, list, desc)
+		if err != nil {
+			return nil, err
+		}
+		return todos, todo.AddTagsByID(todos, item.ID, tags...)
+	})
+}
 
-	todo.PrintTodos(todos)
-	return nil
+func RemoveTags(ctx context.Context, list, desc string, tags []string, store storage.Store) error {
+	return update(ctx, store, func(todos []todo.Item) ([]todo.Item, error) {
+		item, err := todo.FindByDesc(todos, list, desc)
+		if err != nil {
+			return nil, err
+		}
+		return todos, todo.RemoveTagsByID(todos, item.ID, tags...)
+	})
 }
 
-func Add(ctx context.Context, desc string, fs *storage.FileStore) error {
-	todos, err := fs.LoadTodos(ctx)
+func Get(ctx context.Context, id string, store storage.Store) (todo.Item, error) {
+	todos, err := load(ctx, store)
 	if err != nil {
-		return err
+		return todo.Item{}, err
 	}
 
-	todos, err = todo.AddNewItem(todos, desc)
+	return todo.FindByID(todos, id)
+}
+
+// GetInList returns the item with id if it belongs to list.
+func GetInList(ctx context.Context, list, id string, store storage.Store) (todo.Item, error) {
+	todos, err := load(ctx, store)
 	if err != nil {
-		return err
+		return todo.Item{}, err
+	}
+
+	return findInList(todos, list, id)
+}
+
+// findInList looks up id like todo.FindByID, treating an item of another
+// list as missing.
+func findInList(todos []todo.Item, list, id string) (todo.Item, error) {
+	item, err := todo.FindByID(todos, id)
+	if err == nil && !todo.InList(item, list) {
+		err = fmt.Errorf("%w: %s in list %s", todo.ErrItemNotFound, id, list)
 	}
+	return item, err
+}
 
-	if err := fs.SaveTodos(ctx, todos); err != nil {
-		return err
+// FindFuzzy returns the item in list that desc most likely means, allowing
+// for typos. See todo.FindFuzzy.
+func FindFuzzy(ctx context.Context, list, desc string, store storage.Store) (todo.Item, error) {
+	todos, err := load(ctx, store)
+	if err != nil {
+		return todo.Item{}, err
 	}
 
-	return nil
+	return todo.FindFuzzy(todos, list, desc)
 }
 
-func Remove(ctx context.Context, desc string, fs *storage.FileStore) error {
-	todos, err := fs.LoadTodos(ctx)
+// Overdue returns the unfinished items whose deadline is before now.
+func Overdue(ctx context.Context, now time.Time, store storage.Store) ([]todo.Item, error) {
+	todos, err := load(ctx, store)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	todos, err = todo.RemoveItem(todos, desc)
+	return todo.Overdue(todos, now), nil
+}
+
+// DueToday returns the unfinished items due on now's calendar day.
+func DueToday(ctx context.Context, now time.Time, store storage.Store) ([]todo.Item, error) {
+	todos, err := load(ctx, store)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := fs.SaveTodos(ctx, todos); err != nil {
-		return err
+	return todo.DueToday(todos, now), nil
+}
+
+func Add(ctx context.Context, desc string, store storage.Store) (todo.Item, error) {
+	return AddItem(ctx, todo.Item{Description: desc}, store)
+}
+
+// AddItem stores a new item built from draft, see todo.AddItem.
+func AddItem(ctx context.Context, draft todo.Item, store storage.Store) (todo.Item, error) {
+	var created todo.Item
+	err := update(ctx, store, func(todos []todo.Item) ([]todo.Item, error) {
+		todos, err := todo.AddItem(todos, draft)
+		if err != nil {
+			return nil, err
+		}
+		created = todos[len(todos)-1]
+		return todos, nil
+	})
+	if err != nil {
+		return todo.Item{}, err
 	}
 
-	return nil
+	return created, nil
 }
 
-func Update(ctx context.Context, desc string, field todo.UpdateField, newValue string, fs *storage.FileStore) error {
-	todos, err := fs.LoadTodos(ctx)
+// AddSubtask stores a new item built from draft under the item described by
+// parentDesc in list.
+func AddSubtask(ctx context.Context, list, parentDesc string, draft todo.Item, store storage.Store) (todo.Item, error) {
+	var created todo.Item
+	err := update(ctx, store, func(todos []todo.Item) ([]todo.Item, error) {
+		parent, err := todo.FindByDesc(todos, list, parentDesc)
+		if err != nil {
+			return nil, err
+		}
+		draft.ParentID = parent.ID
+		todos, err = todo.AddItem(todos, draft)
+		if err != nil {
+			return nil, err
+		}
+		created = todos[len(todos)-1]
+		return todos, nil
+	})
 	if err != nil {
-		return err
+		return todo.Item{}, err
 	}
 
+	return created, nil
+}
+
+// Remove moves desc and its subtasks to the trash, see Restore and Purge.
+func Remove(ctx context.Context, list, desc string, store storage.Store) error {
+	return update(ctx, store, func(todos []todo.Item) ([]todo.Item, error) {
+		item, err := todo.FindByDesc(todos, list, desc)
+		if err != nil {
+			return nil, err
+		}
+		return todos, todo.TrashByID(todos, item.ID)
+	})
+}
+
+func RemoveByID(ctx context.Context, id string, store storage.Store) error {
+	return update(ctx, store, func(todos []todo.Item) ([]todo.Item, error) {
+		return todos, todo.TrashByID(todos, id)
+	})
+}
+
+// RemoveInList moves the item with id to the trash if it belongs to list.
+func RemoveInList(ctx context.Context, list, id string, store storage.Store) error {
+	return update(ctx, store, func(todos []todo.Item) ([]todo.Item, error) {
+		if _, err := findInList(todos, list, id); err != nil {
+			return nil, err
+		}
+		return todos, todo.TrashByID(todos, id)
+	})
+}
+
+func Update(ctx context.Context, list, desc string, field todo.UpdateField, newValue string, store storage.Store) error {
+	return update(ctx, store, func(todos []todo.Item) ([]todo.Item, error) {
+		item, err := todo.FindByDesc(todos, list, desc)
+		if err != nil {
+			return nil, err
+		}
+		return updateField(todos, item.ID, field, newValue)
+	})
+}
+
+func UpdateByID(ctx context.Context, id string, field todo.UpdateField, newValue string, store storage.Store) error {
+	return update(ctx, store, func(todos []todo.Item) ([]todo.Item, error) {
+		return updateField(todos, id, field, newValue)
+	})
+}
+
+// UpdateInList changes one field of the item with id if it belongs to list.
+func UpdateInList(ctx context.Context, list, id string, field todo.UpdateField, newValue string, store storage.Store) error {
+	return update(ctx, store, func(todos []todo.Item) ([]todo.Item, error) {
+		if _, err := findInList(todos, list, id); err != nil {
+			return nil, err
+		}
+		return updateField(todos, id, field, newValue)
+	})
+}
+
+// updateField changes one field of an item. Completing a recurring item
+// adds its next occurrence.
+func updateField(todos []todo.Item, id string, field todo.UpdateField, newValue string) ([]todo.Item, error) {
+	var err error
 	switch field {
 	case todo.UpdateFieldDescription:
-		if err := todo.UpdateDesc(todos, desc, newValue); err != nil {
-			return err
-		}
+		err = todo.UpdateDescByID(todos, id, newValue)
 	case todo.UpdateFieldStatus:
-		if err := todo.UpdateStatus(todos, desc, newValue); err != nil {
-			return err
+		if err = todo.UpdateStatusByID(todos, id, newValue); err == nil {
+			return todo.Recur(todos, id, todo.Now())
 		}
+	case todo.UpdateFieldDue:
+		err = todo.UpdateDueByID(todos, id, newValue)
+	case todo.UpdateFieldPriority:
+		err = todo.UpdatePriorityByID(todos, id, newValue)
+	case todo.UpdateFieldTags:
+		err = todo.UpdateTagsByID(todos, id, newValue)
+	case todo.UpdateFieldList:
+		err = todo.MoveToListByID(todos, id, newValue)
+	case todo.UpdateFieldRecurrence:
+		err = todo.UpdateRecurrenceByID(todos, id, newValue)
+	case todo.UpdateFieldNotes:
+		err = todo.UpdateNotesByID(todos, id, newValue)
 	default:
-		return fmt.Errorf("%w: %s - valid fields are: %s, %s", ErrInvalidUpdateField, field, todo.UpdateFieldDescription, todo.UpdateFieldStatus)
+		err = fmt.Errorf("%w: %s - valid fields are: %s", ErrInvalidUpdateField, field, validFields())
 	}
+	return todos, err
+}
 
-	if err := fs.SaveTodos(ctx, todos); err != nil {
-		return err
+func validFields() string {
+	fields := []string{
+		string(todo.UpdateFieldDescription),
+		string(todo.UpdateFieldStatus),
+		string(todo.UpdateFieldDue),
+		string(todo.UpdateFieldPriority),
+		string(todo.UpdateFieldTags),
+		string(todo.UpdateFieldList),
+		string(todo.UpdateFieldRecurrence),
+		string(todo.UpdateFieldNotes),
+	}
+	return strings.Join(fields, ", ")
+}
+
+// Lists returns a summary of every list that holds items, not counting
+// archived ones.
+func Lists(ctx context.Context, store storage.Store) ([]todo.ListSummary, error) {
+	todos, err := load(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return todo.Lists(todo.Unarchived(todos)), nil
+}
+
+func RenameList(ctx context.Context, from, to string, store storage.Store) error {
+	return update(ctx, store, func(todos []todo.Item) ([]todo.Item, error) {
+		return todos, todo.RenameList(todos, from, to)
+	})
+}
+
+// DeleteList moves every item of a list to the trash.
+func DeleteList(ctx context.Context, name string, store storage.Store) error {
+	return update(ctx, store, func(todos []todo.Item) ([]todo.Item, error) {
+		return todos, todo.TrashList(todos, name)
+	})
+}
+
+// AddDependency makes desc wait for dependsOn, both looked up in list.
+func AddDependency(ctx context.Context, list, desc, dependsOn string, store storage.Store) error {
+	return update(ctx, store, func(todos []todo.Item) ([]todo.Item, error) {
+		item, dependency, err := findPair(todos, list, desc, dependsOn)
+		if err != nil {
+			return nil, err
+		}
+		return todos, todo.AddDependencyByID(todos, item.ID, dependency.ID)
+	})
+}
+
+func AddDependencyByID(ctx context.Context, id, dependsOn string, store storage.Store) error {
+	return update(ctx, store, func(todos []todo.Item) ([]todo.Item, error) {
+		return todos, todo.AddDependencyByID(todos, id, dependsOn)
+	})
+}
+
+func RemoveDependency(ctx context.Context, list, desc, dependsOn string, store storage.Store) error {
+	return update(ctx, store, func(todos []todo.Item) ([]todo.Item, error) {
+		item, dependency, err := findPair(todos, list, desc, dependsOn)
+		if err != nil {
+			return nil, err
+		}
+		return todos, todo.RemoveDependencyByID(todos, item.ID, dependency.ID)
+	})
+}
+
+func RemoveDependencyByID(ctx context.Context, id, dependsOn string, store storage.Store) error {
+	return update(ctx, store, func(todos []todo.Item) ([]todo.Item, error) {
+		return todos, todo.RemoveDependencyByID(todos, id, dependsOn)
+	})
+}
+
+// Blocked returns the unfinished items waiting on an unfinished dependency.
+func Blocked(ctx context.Context, store storage.Store) ([]todo.Item, error) {
+	todos, err := load(ctx, store)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return todo.Blocked(todos), nil
+}
+
+// Ready returns the unfinished items that can be worked on now.
+func Ready(ctx context.Context, store storage.Store) ([]todo.Item, error) {
+	todos, err := load(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return todo.Ready(todos), nil
+}
+
+// History returns the audit trail of the item described by desc in list,
+// oldest first.
+func History(ctx context.Context, list, desc string, store storage.Store) ([]storage.Event, error) {
+	todos, err := load(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := todo.FindByDesc(todos, list, desc)
+	if err != nil {
+		return nil, err
+	}
+	return HistoryByID(ctx, item.ID, store)
+}
+
+// HistoryByID returns the audit trail of an item, which is kept after the
+// item is removed. An item that was never recorded and does not exist is
+// reported as todo.ErrItemNotFound.
+func HistoryByID(ctx context.Context, id string, store storage.Store) ([]storage.Event, error) {
+	events, err := store.History(ctx, id)
+	if err != nil || len(events) > 0 {
+		return events, err
+	}
+
+	if _, err := Get(ctx, id, store); err != nil {
+		return nil, err
+	}
+	return []storage.Event{}, nil
+}
+
+func findPair(todos []todo.Item, list, desc, other string) (todo.Item, todo.Item, error) {
+	item, err := todo.FindByDesc(todos, list, desc)
+	if err != nil {
+		return todo.Item{}, todo.Item{}, err
+	}
+	otherItem, err := todo.FindByDesc(todos, list, other)
+	if err != nil {
+		return todo.Item{}, todo.Item{}, err
+	}
+	return item, otherItem, nil
 }
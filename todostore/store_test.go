@@ -0,0 +1,113 @@
+package todostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"todo-app/storage"
+	"todo-app/todo"
+)
+
+func TestConcurrentAdd(t *testing.T) {
+	fs := storage.NewFileStore(t.TempDir() + "/todos.json")
+	defer fs.Close()
+
+	const numWriters = 100
+	var wg sync.WaitGroup
+	wg.Add(numWriters)
+
+	errors := make(chan error, numWriters)
+
+	for i := range numWriters {
+		go func() {
+			defer wg.Done()
+			if _, err := Add(context.Background(), fmt.Sprintf("task %d", i), fs); err != nil {
+				errors <- err
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errors)
+
+	for err := range errors {
+		t.Errorf("concurrent add failed: %v", err)
+	}
+
+	todos, err := fs.LoadTodos(context.Background())
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	if len(todos) != numWriters {
+		t.Errorf("expected %d todos, got %d", numWriters, len(todos))
+	}
+}
+
+func TestUpdateCompletesRecurringItem(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+
+	created, err := AddItem(ctx, todo.Item{Description: "rotate on-call", Recurrence: "weekly"}, store)
+	if err != nil {
+		t.Fatalf("AddItem failed: %v", err)
+	}
+
+	if err := Update(ctx, "", "rotate on-call", todo.UpdateFieldStatus, todo.Completed, store); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	todos, err := store.LoadTodos(ctx)
+	if err != nil {
+		t.Fatalf("LoadTodos failed: %v", err)
+	}
+	if len(todos) != 2 {
+		t.Fatalf("expected the next occurrence to be added, got %+v", todos)
+	}
+	if todos[0].ID != created.ID || todos[0].Status != todo.Completed {
+		t.Errorf("expected the completed occurrence to be kept, got %+v", todos[0])
+	}
+	if todos[1].Status != todo.NotStarted || todos[1].Due.IsZero() {
+		t.Errorf("expected an open occurrence with a due date, got %+v", todos[1])
+	}
+}
+
+func TestInListChecksTheList(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+
+	created, err := AddItem(ctx, todo.Item{Description: "standup", List: "work"}, store)
+	if err != nil {
+		t.Fatalf("AddItem failed: %v", err)
+	}
+
+	if _, err := GetInList(ctx, "home", created.ID, store); !errors.Is(err, todo.ErrItemNotFound) {
+		t.Errorf("GetInList: expected ErrItemNotFound, got %v", err)
+	}
+	if err := UpdateInList(ctx, "home", created.ID, todo.UpdateFieldNotes, "daily", store); !errors.Is(err, todo.ErrItemNotFound) {
+		t.Errorf("UpdateInList: expected ErrItemNotFound, got %v", err)
+	}
+	if err := RemoveInList(ctx, "home", created.ID, store); !errors.Is(err, todo.ErrItemNotFound) {
+		t.Errorf("RemoveInList: expected ErrItemNotFound, got %v", err)
+	}
+
+	if err := UpdateInList(ctx, "work", created.ID, todo.UpdateFieldNotes, "daily", store); err != nil {
+		t.Fatalf("UpdateInList failed: %v", err)
+	}
+	item, err := GetInList(ctx, "work", created.ID, store)
+	if err != nil {
+		t.Fatalf("GetInList failed: %v", err)
+	}
+	if item.Notes != "daily" {
+		t.Errorf("expected the notes to be updated, got %q", item.Notes)
+	}
+	if err := RemoveInList(ctx, "work", created.ID, store); err != nil {
+		t.Fatalf("RemoveInList failed: %v", err)
+	}
+	if _, err := Get(ctx, created.ID, store); !errors.Is(err, todo.ErrItemNotFound) {
+		t.Errorf("expected the item to be in the trash, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package todostore
+
+import (
+	"context"
+	"time"
+
+	"todo-app/storage"
+	"todo-app/todo"
+)
+
+// Trash returns the removed items that have not been purged yet, most
+// recently removed first.
+func Trash(ctx context.Context, store storage.Store) ([]todo.Item, error) {
+	todos, err := store.LoadTodos(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return todo.Trash(todos), nil
+}
+
+// Restore takes the most recently removed item called desc in list out of
+// the trash.
+func Restore(ctx context.Context, list, desc string, store storage.Store) error {
+	return store.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+		item, err := todo.FindTrashedByDesc(todos, list, desc)
+		if err != nil {
+			return nil, err
+		}
+		return todos, todo.RestoreByID(todos, item.ID)
+	})
+}
+
+func RestoreByID(ctx context.Context, id string, store storage.Store) error {
+	return store.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+		return todos, todo.RestoreByID(todos, id)
+	})
+}
+
+// Purge permanently deletes the most recently removed item called desc in
+// list.
+func Purge(ctx context.Context, list, desc string, store storage.Store) error {
+	return store.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+		item, err := todo.FindTrashedByDesc(todos, list, desc)
+		if err != nil {
+			return nil, err
+		}
+		return todo.PurgeByID(todos, item.ID)
+	})
+}
+
+func PurgeByID(ctx context.Context, id string, store storage.Store) error {
+	return store.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+		return todo.PurgeByID(todos, id)
+	})
+}
+
+// PurgeTrash permanently deletes the items removed at or before cutoff and
+// returns how many there were. Nothing is written when there are none.
+func PurgeTrash(ctx context.Context, cutoff time.Time, store storage.Store) (int, error) {
+	todos, err := store.LoadTodos(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if _, n := todo.PurgeTrash(todos, cutoff); n == 0 {
+		return 0, nil
+	}
+
+	var purged int
+	err = store.Update(ctx, func(todos []todo.Item) ([]todo.Item, error) {
+		todos, purged = todo.PurgeTrash(todos, cutoff)
+		return todos, nil
+	})
+	return purged, err
+}
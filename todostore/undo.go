@@ -0,0 +1,190 @@
+package todostore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"todo-app/storage"
+	"todo-app/todo"
+)
+
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+	ErrUndoConflict  = errors.New("item changed since")
+	ErrNoActor       = errors.New("undo and redo need an actor")
+)
+
+// operation is the events one caller wrote under one trace ID, such as a
+// single CLI command or HTTP request. It is identified by its first Seq.
+type operation struct {
+	seq    int64
+	events []storage.Event
+}
+
+// Undo reverts the last n operations made by the actor in ctx, newest
+// first, and returns how many it reverted. Removed items come back, and
+// updated items get their previous values. An operation whose items have
+// been changed since by someone else is refused with ErrUndoConflict.
+func Undo(ctx context.Context, n int, store storage.Store) (int, error) {
+	return replay(ctx, n, store, true)
+}
+
+// Redo re-applies the last n operations reverted by Undo. Any new operation
+// by the same actor clears what can be redone.
+func Redo(ctx context.Context, n int, store storage.Store) (int, error) {
+	return replay(ctx, n, store, false)
+}
+
+func replay(ctx context.Context, n int, store storage.Store, undo bool) (int, error) {
+	// Changes without an actor cannot be told apart by caller, so they are
+	// nobody's to undo.
+	if storage.Actor(ctx) == "" {
+		return 0, ErrNoActor
+	}
+
+	for done := range n {
+		events, err := store.HistoryByActor(ctx, storage.Actor(ctx))
+		if err != nil {
+			return done, err
+		}
+
+		undoable, redoable := stacks(operations(events))
+		stack, nothing := undoable, ErrNothingToUndo
+		if !undo {
+			stack, nothing = redoable, ErrNothingToRedo
+		}
+		if len(stack) == 0 {
+			if done > 0 {
+				return done, nil
+			}
+			return 0, nothing
+		}
+		op := stack[len(stack)-1]
+
+		if undo {
+			err = store.Update(storage.WithUndo(ctx, op.seq), func(todos []todo.Item) ([]todo.Item, error) {
+				return revert(todos, op.events)
+			})
+		} else {
+			err = store.Update(storage.WithRedo(ctx, op.seq), func(todos []todo.Item) ([]todo.Item, error) {
+				return reapply(todos, op.events)
+			})
+		}
+		if err != nil {
+			return done, err
+		}
+	}
+	return n, nil
+}
+
+// operations groups consecutive events of the same trace that undo or redo
+// the same thing.
+func operations(events []storage.Event) []operation {
+	var ops []operation
+	for _, event := range events {
+		if len(ops) > 0 {
+			last := &ops[len(ops)-1]
+			first := last.events[0]
+			if first.TraceID == event.TraceID && first.Undoes == event.Undoes && first.Redoes == event.Redoes {
+				last.events = append(last.events, event)
+				continue
+			}
+		}
+		ops = append(ops, operation{seq: event.Seq, events: []storage.Event{event}})
+	}
+	return ops
+}
+
+// stacks replays ops to find what can be undone and redone, most recent
+// last. An undo moves its operation to the redo stack; a redo goes back on
+// the undo stack as an operation of its own.
+func stacks(ops []operation) (undoable, redoable []operation) {
+	for _, op := range ops {
+		first := op.events[0]
+		switch {
+		case first.Undoes != 0:
+			if i := indexOfOperation(undoable, first.Undoes); i >= 0 {
+				redoable = append(redoable, undoable[i])
+				undoable = slices.Delete(undoable, i, i+1)
+			}
+		case first.Redoes != 0:
+			if i := indexOfOperation(redoable, first.Redoes); i >= 0 {
+				redoable = slices.Delete(redoable, i, i+1)
+			}
+			undoable = append(undoable, op)
+		default:
+			undoable = append(undoable, op)
+			redoable = nil
+		}
+	}
+	return undoable, redoable
+}
+
+func indexOfOperation(ops []operation, seq int64) int {
+	return slices.IndexFunc(ops, func(op operation) bool { return op.seq == seq })
+}
+
+// revert puts every item touched by events back the way it was, newest event
+// first.
+func revert(todos []todo.Item, events []storage.Event) ([]todo.Item, error) {
+	for _, event := range slices.Backward(events) {
+		var err error
+		todos, err = swap(todos, event.ItemID, event.After, event.Before)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return todos, nil
+}
+
+// reapply makes the changes recorded in events again, oldest event first.
+func reapply(todos []todo.Item, events []storage.Event) ([]todo.Item, error) {
+	for _, event := range events {
+		var err error
+		todos, err = swap(todos, event.ItemID, event.Before, event.After)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return todos, nil
+}
+
+// swap replaces the item with the given ID, which must currently look like
+// from, by to. A nil from means the item must not exist and a nil to removes
+// it. Restored items go to the end of the list.
+func swap(todos []todo.Item, id string, from, to *todo.Item) ([]todo.Item, error) {
+	i := slices.IndexFunc(todos, func(item todo.Item) bool { return item.ID == id })
+
+	switch {
+	case from == nil && i >= 0:
+		return nil, fmt.Errorf("%w: %s was added again", ErrUndoConflict, todos[i].Description)
+	case from != nil && i < 0:
+		return nil, fmt.Errorf("%w: %s was removed", ErrUndoConflict, from.Description)
+	case from != nil && !sameItem(todos[i], *from):
+		return nil, fmt.Errorf("%w: %s was changed", ErrUndoConflict, from.Description)
+	}
+
+	switch {
+	case to == nil:
+		return slices.Delete(todos, i, i+1), nil
+	case i < 0:
+		return append(todos, to.Clone()), nil
+	default:
+		todos[i] = to.Clone()
+		return todos, nil
+	}
+}
+
+// sameItem compares items as they are stored, ignoring UpdatedAt, which
+// changes without being recorded in the history.
+func sameItem(a, b todo.Item) bool {
+	a.UpdatedAt, b.UpdatedAt = time.Time{}, time.Time{}
+	dataA, errA := json.Marshal(a)
+	dataB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(dataA) == string(dataB)
+}
@@ -0,0 +1,135 @@
+package todostore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"todo-app/storage"
+	"todo-app/todo"
+)
+
+// as returns the context of a new request by actor.
+func as(actor string) context.Context {
+	return storage.WithActor(storage.WithTraceID(context.Background(), todo.NewID()), actor)
+}
+
+func TestUndoAndRedo(t *testing.T) {
+	store := storage.NewMemoryStore()
+
+	if _, err := Add(as("alice"), "write report", store); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := Update(as("alice"), "", "write report", todo.UpdateFieldStatus, todo.Completed, store); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := Remove(as("alice"), "", "write report", store); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := Add(as("bob"), "file taxes", store); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	alice := as("alice")
+	steps := []struct {
+		name   string
+		redo   bool
+		n      int
+		done   int
+		status string
+	}{
+		{"undo remove", false, 1, 1, todo.Completed},
+		{"undo status change", false, 1, 1, todo.NotStarted},
+		{"redo status change", true, 1, 1, todo.Completed},
+		{"undo everything", false, 5, 2, ""},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			replay := Undo
+			if step.redo {
+				replay = Redo
+			}
+			done, err := replay(alice, step.n, store)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			if done != step.done {
+				t.Errorf("expected %d operations, got %d", step.done, done)
+			}
+
+			todos, err := store.LoadTodos(alice)
+			if err != nil {
+				t.Fatalf("LoadTodos failed: %v", err)
+			}
+			item, err := todo.FindByDesc(todos, "", "write report")
+			switch {
+			case step.status == "" && err == nil:
+				t.Errorf("expected the item to be gone, got %+v", item)
+			case step.status != "" && err != nil:
+				t.Errorf("expected the item to exist: %v", err)
+			case item.Status != step.status:
+				t.Errorf("expected status %q, got %q", step.status, item.Status)
+			}
+			if _, err := todo.FindByDesc(todos, "", "file taxes"); err != nil {
+				t.Errorf("expected bob's item to be left alone: %v", err)
+			}
+		})
+	}
+
+	if _, err := Undo(as("alice"), 1, store); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("expected %v, got %v", ErrNothingToUndo, err)
+	}
+}
+
+func TestNewOperationClearsRedo(t *testing.T) {
+	store := storage.NewMemoryStore()
+
+	if _, err := Add(as("alice"), "write report", store); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if _, err := Undo(as("alice"), 1, store); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if _, err := Add(as("alice"), "file taxes", store); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	if _, err := Redo(as("alice"), 1, store); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("expected %v, got %v", ErrNothingToRedo, err)
+	}
+}
+
+func TestUndoNeedsAnActor(t *testing.T) {
+	store := storage.NewMemoryStore()
+
+	if _, err := Add(as(""), "write report", store); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if _, err := Undo(as(""), 1, store); !errors.Is(err, ErrNoActor) {
+		t.Errorf("expected %v, got %v", ErrNoActor, err)
+	}
+}
+
+func TestUndoRefusesItemsChangedByOthers(t *testing.T) {
+	store := storage.NewMemoryStore()
+
+	if _, err := Add(as("alice"), "write report", store); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := Update(as("bob"), "", "write report", todo.UpdateFieldDescription, "write the report", store); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	if _, err := Undo(as("alice"), 1, store); !errors.Is(err, ErrUndoConflict) {
+		t.Errorf("expected %v, got %v", ErrUndoConflict, err)
+	}
+
+	todos, err := store.LoadTodos(context.Background())
+	if err != nil {
+		t.Fatalf("LoadTodos failed: %v", err)
+	}
+	if len(todos) != 1 {
+		t.Errorf("expected the refused undo to change nothing, got %+v", todos)
+	}
+}
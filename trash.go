@@ -0,0 +1,85 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"todo-app/todo"
+	"todo-app/todostore"
+)
+
+// DefaultTrashRetention is how long removed items stay in the trash before
+// the server purges them.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+type PurgeResponse struct {
+	TraceID string
+	Purged  int
+}
+
+func (a *App) TrashHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+
+	todos, err := todostore.Trash(ctx, a.Store)
+	if err != nil {
+		writeStoreError(w, err, "failed to fetch trash", traceID)
+		slog.ErrorContext(ctx, "failed to fetch trash", "traceID", traceID, "error", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, TodosResponse{TraceID: traceID, Todos: todos})
+}
+
+func (a *App) RestoreHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+	id := r.PathValue("id")
+
+	slog.InfoContext(ctx, "Restoring todo", "id", id, "traceID", traceID)
+
+	if err := todostore.RestoreByID(ctx, id, a.Store); err != nil {
+		writeStoreError(w, err, "failed to restore item", traceID)
+		slog.ErrorContext(ctx, "failed to restore item", "id", id, "traceID", traceID, "error", err)
+		return
+	}
+
+	a.GetTodoHandler(w, r)
+}
+
+func (a *App) PurgeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+	id := r.PathValue("id")
+
+	slog.InfoContext(ctx, "Purging todo", "id", id, "traceID", traceID)
+
+	if err := todostore.PurgeByID(ctx, id, a.Store); err != nil {
+		writeStoreError(w, err, "failed to purge item", traceID)
+		slog.ErrorContext(ctx, "failed to purge item", "id", id, "traceID", traceID, "error", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "Todo purged",
+		"traceID": traceID,
+	})
+}
+
+// EmptyTrashHandler purges everything in the trash.
+func (a *App) EmptyTrashHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	traceID := ctx.Value(traceIDKey).(string)
+
+	slog.InfoContext(ctx, "Emptying trash", "traceID", traceID)
+
+	purged, err := todostore.PurgeTrash(ctx, todo.Now(), a.Store)
+	if err != nil {
+		writeStoreError(w, err, "failed to empty trash", traceID)
+		slog.ErrorContext(ctx, "failed to empty trash", "traceID", traceID, "error", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, PurgeResponse{TraceID: traceID, Purged: purged})
+}